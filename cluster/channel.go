@@ -14,7 +14,6 @@
 package cluster
 
 import (
-	"sync"
 	"time"
 
 	"github.com/f1shl3gs/gossiping/cluster/clusterpb"
@@ -24,21 +23,45 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// reliableQueueSize bounds the oversized messages waiting to be
+	// dispatched, and the ones waiting to be sent to each peer
+	reliableQueueSize = 200
+
+	// reliableAttempts is the number of attempts of sending an oversized
+	// message to a peer
+	reliableAttempts = 3
+
+	pathGossip   = "gossip"
+	pathReliable = "reliable"
+)
+
+// reliableRetryInterval is doubled after each failed attempt
+var reliableRetryInterval = time.Second
+
 // Channel allows clients to send messages for a specific state type that will be
-// broadcasted in a best-effort manner.
+// broadcasted in a best-effort manner. Small messages are piggybacked on
+// gossip over UDP, oversized ones are sent to every peer over TCP, and
+// retried a few times on failures. Every peer has its own queue, so a slow
+// peer delays only its own messages. Messages are never delivered to the
+// local node, the sender should merge them itself.
 type Channel struct {
 	key          string
 	send         func([]byte)
 	peers        func() []*memberlist.Node
 	sendOversize func(*memberlist.Node, []byte) error
+	stopc        chan struct{}
 
-	msgc   chan []byte
-	logger *zap.Logger
+	msgc          chan []byte
+	retryInterval time.Duration
+	logger        *zap.Logger
 
 	oversizeGossipMessageFailureTotal prometheus.Counter
 	oversizeGossipMessageDroppedTotal prometheus.Counter
 	oversizeGossipMessageSentTotal    prometheus.Counter
 	oversizeGossipDuration            prometheus.Histogram
+	messages                          *prometheus.CounterVec
+	retries                           prometheus.Counter
 }
 
 // NewChannel creates a new Channel struct, which handles sending normal and
@@ -72,20 +95,41 @@ func NewChannel(
 		Help:        "Duration of oversized gossip message requests.",
 		ConstLabels: prometheus.Labels{"key": key},
 	})
+	messages := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "gossiping_channel_messages_total",
+		Help:        "Number of messages by path and outcome. Gossip messages are counted once queued for broadcast, or if they fail to encode, their transmission is not tracked. Reliable messages are counted per peer once delivered, failed or dropped.",
+		ConstLabels: prometheus.Labels{"key": key},
+	}, []string{"path", "outcome"})
+	retries := prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "gossiping_channel_reliable_retries_total",
+		Help:        "Number of retries of sending oversized messages.",
+		ConstLabels: prometheus.Labels{"key": key},
+	})
+
+	messages.WithLabelValues(pathGossip, "queued")
+	messages.WithLabelValues(pathGossip, "encode_failed")
+	messages.WithLabelValues(pathReliable, "delivered")
+	messages.WithLabelValues(pathReliable, "failed")
+	messages.WithLabelValues(pathReliable, "dropped")
 
-	reg.MustRegister(oversizeGossipDuration, oversizeGossipMessageFailureTotal, oversizeGossipMessageDroppedTotal, oversizeGossipMessageSentTotal)
+	reg.MustRegister(oversizeGossipDuration, oversizeGossipMessageFailureTotal, oversizeGossipMessageDroppedTotal, oversizeGossipMessageSentTotal,
+		messages, retries)
 
 	c := &Channel{
 		key:                               key,
 		send:                              send,
 		peers:                             peers,
+		stopc:                             stopc,
 		logger:                            logger,
-		msgc:                              make(chan []byte, 200),
+		msgc:                              make(chan []byte, reliableQueueSize),
+		retryInterval:                     reliableRetryInterval,
 		sendOversize:                      sendOversize,
 		oversizeGossipMessageFailureTotal: oversizeGossipMessageFailureTotal,
 		oversizeGossipMessageDroppedTotal: oversizeGossipMessageDroppedTotal,
 		oversizeGossipMessageSentTotal:    oversizeGossipMessageSentTotal,
 		oversizeGossipDuration:            oversizeGossipDuration,
+		messages:                          messages,
+		retries:                           retries,
 	}
 
 	go c.handleOverSizedMessages(stopc)
@@ -93,62 +137,162 @@ func NewChannel(
 	return c
 }
 
-// handleOverSizedMessages prevents memberlist from opening too many parallel
-// TCP connections to its peers.
+// reliableMessage is an oversized message to send to the node
+type reliableMessage struct {
+	node *memberlist.Node
+	b    []byte
+}
+
+// peerQueue holds the oversized messages waiting to be sent to a peer
+type peerQueue struct {
+	msgc chan reliableMessage
+	done chan struct{}
+}
+
+// handleOverSizedMessages dispatches oversized messages to the queues of
+// the peers, a worker per peer sends them in order, which prevents
+// memberlist from opening too many parallel TCP connections to its peers.
 func (c *Channel) handleOverSizedMessages(stopc chan struct{}) {
-	var wg sync.WaitGroup
+	queues := make(map[string]*peerQueue)
+	defer func() {
+		for _, q := range queues {
+			close(q.done)
+		}
+	}()
+
 	for {
 		select {
 		case b := <-c.msgc:
-			for _, n := range c.peers() {
-				wg.Add(1)
-				go func(n *memberlist.Node) {
-					defer wg.Done()
-					c.oversizeGossipMessageSentTotal.Inc()
-					start := time.Now()
-					if err := c.sendOversize(n, b); err != nil {
-						c.logger.Debug("failed to send reliable",
-							zap.String("key", c.key),
-							zap.String("node", n.Address()),
-							zap.Error(err))
-						c.oversizeGossipMessageFailureTotal.Inc()
-						return
+			peers := c.peers()
+			current := make(map[string]struct{}, len(peers))
+			for _, n := range peers {
+				current[n.Name] = struct{}{}
+
+				q := queues[n.Name]
+				if q == nil {
+					q = &peerQueue{
+						msgc: make(chan reliableMessage, reliableQueueSize),
+						done: make(chan struct{}),
 					}
-					c.oversizeGossipDuration.Observe(time.Since(start).Seconds())
-				}(n)
+					queues[n.Name] = q
+					go c.deliver(q)
+				}
+
+				select {
+				case q.msgc <- reliableMessage{node: n, b: b}:
+				default:
+					c.logger.Warn("oversized gossip queue of peer full",
+						zap.String("key", c.key),
+						zap.String("node", n.Address()))
+					c.oversizeGossipMessageDroppedTotal.Inc()
+					c.messages.WithLabelValues(pathReliable, "dropped").Inc()
+				}
 			}
 
-			wg.Wait()
+			// stop the workers of the peers gone
+			for name, q := range queues {
+				if _, found := current[name]; !found {
+					close(q.done)
+					delete(queues, name)
+				}
+			}
 		case <-stopc:
 			return
 		}
 	}
 }
 
-// Broadcast enqueues a message for broadcasting.
+// deliver sends the messages of the queue one by one until it's done
+func (c *Channel) deliver(q *peerQueue) {
+	for {
+		select {
+		case m := <-q.msgc:
+			c.sendReliable(m.node, m.b)
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// sendReliable sends the message to the peer, failed attempts are retried
+// with backoff unless the peer is gone.
+func (c *Channel) sendReliable(n *memberlist.Node, b []byte) {
+	backoff := c.retryInterval
+	for attempt := 1; ; attempt++ {
+		c.oversizeGossipMessageSentTotal.Inc()
+		start := time.Now()
+		err := c.sendOversize(n, b)
+		if err == nil {
+			c.oversizeGossipDuration.Observe(time.Since(start).Seconds())
+			c.messages.WithLabelValues(pathReliable, "delivered").Inc()
+			return
+		}
+
+		c.oversizeGossipMessageFailureTotal.Inc()
+		if attempt >= reliableAttempts || !c.isPeer(n) {
+			c.logger.Warn("failed to send reliable",
+				zap.String("key", c.key),
+				zap.String("node", n.Address()),
+				zap.Int("attempts", attempt),
+				zap.Error(err))
+			c.messages.WithLabelValues(pathReliable, "failed").Inc()
+			return
+		}
+
+		c.logger.Debug("retry sending reliable",
+			zap.String("key", c.key),
+			zap.String("node", n.Address()),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+		c.retries.Inc()
+
+		select {
+		case <-time.After(backoff):
+		case <-c.stopc:
+			return
+		}
+
+		backoff *= 2
+	}
+}
+
+// isPeer returns true if the node is still a peer
+func (c *Channel) isPeer(n *memberlist.Node) bool {
+	for _, p := range c.peers() {
+		if p.Name == n.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Broadcast enqueues a message for broadcasting, oversized messages are
+// dropped if the queue of them is full.
 func (c *Channel) Broadcast(b []byte) {
 	b, err := proto.Marshal(&clusterpb.Part{Key: c.key, Data: b})
 	if err != nil {
+		c.logger.Warn("encode broadcast failed",
+			zap.String("key", c.key),
+			zap.Error(err))
+		c.messages.WithLabelValues(pathGossip, "encode_failed").Inc()
+		return
+	}
+
+	if !OversizedMessage(b) {
+		c.send(b)
+		c.messages.WithLabelValues(pathGossip, "queued").Inc()
 		return
 	}
 
 	select {
 	case c.msgc <- b:
 	default:
-		c.logger.Debug("oversized gossip channel full")
+		c.logger.Warn("oversized gossip channel full",
+			zap.String("key", c.key))
 		c.oversizeGossipMessageDroppedTotal.Inc()
+		c.messages.WithLabelValues(pathReliable, "dropped").Inc()
 	}
-	/*
-		if OversizedMessage(b) {
-			select {
-			case c.msgc <- b:
-			default:
-				c.logger.Debug("oversized gossip channel full")
-				c.oversizeGossipMessageDroppedTotal.Inc()
-			}
-		} else {
-			c.send(b)
-		}*/
 }
 
 // OversizedMessage indicates whether or not the byte payload should be sent
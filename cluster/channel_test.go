@@ -16,13 +16,19 @@ package cluster
 import (
 	"bytes"
 	"context"
-	"go.uber.org/zap/zaptest"
+	"errors"
 	"io"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/memberlist"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
 )
 
 func TestNormalMessagesGossiped(t *testing.T) {
@@ -39,6 +45,7 @@ func TestNormalMessagesGossiped(t *testing.T) {
 	if sent != true {
 		t.Fatalf("small message not sent")
 	}
+	require.Equal(t, float64(1), testutil.ToFloat64(c.messages.WithLabelValues(pathGossip, "queued")))
 }
 
 func TestOversizedMessagesGossiped(t *testing.T) {
@@ -74,6 +81,99 @@ func TestOversizedMessagesGossiped(t *testing.T) {
 	}
 }
 
+func TestOversizedMessagesRetried(t *testing.T) {
+	old := reliableRetryInterval
+	reliableRetryInterval = time.Millisecond
+	defer func() {
+		reliableRetryInterval = old
+	}()
+
+	var (
+		mtx      sync.Mutex
+		attempts = map[string]int{}
+		done     = make(chan struct{}, 2)
+	)
+	c := newChannel(
+		t,
+		func(_ []byte) {},
+		func() []*memberlist.Node { return []*memberlist.Node{{Name: "flaky"}, {Name: "down"}} },
+		func(n *memberlist.Node, _ []byte) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			attempts[n.Name]++
+			if n.Name == "flaky" && attempts[n.Name] == 2 {
+				done <- struct{}{}
+				return nil
+			}
+			if n.Name == "down" && attempts[n.Name] == reliableAttempts {
+				done <- struct{}{}
+			}
+
+			return errors.New("connection refused")
+		},
+	)
+
+	c.Broadcast(make([]byte, maxGossipPacketSize))
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("oversized message not retried")
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.messages.WithLabelValues(pathReliable, "delivered")) == 1 &&
+			testutil.ToFloat64(c.messages.WithLabelValues(pathReliable, "failed")) == 1
+	}, time.Second, time.Millisecond)
+	// one retry of flaky and all retries of down
+	require.Equal(t, float64(1+reliableAttempts-1), testutil.ToFloat64(c.retries))
+}
+
+func TestOversizedMessagesNotBlockedBySlowPeer(t *testing.T) {
+	var (
+		blackhole = make(chan struct{})
+		delivered = make(chan []byte, 16)
+	)
+	stopc := make(chan struct{})
+	defer close(stopc)
+	defer close(blackhole)
+
+	// the blackhole keeps logging once the test completes
+	c := NewChannel(
+		"test",
+		func(_ []byte) {},
+		func() []*memberlist.Node { return []*memberlist.Node{{Name: "blackhole"}, {Name: "healthy"}} },
+		func(n *memberlist.Node, b []byte) error {
+			if n.Name == "blackhole" {
+				<-blackhole
+				return errors.New("i/o timeout")
+			}
+
+			delivered <- b
+			return nil
+		},
+		zap.NewNop(),
+		stopc,
+		prometheus.NewRegistry(),
+	)
+
+	for i := 0; i < 10; i++ {
+		c.Broadcast(bytes.Repeat([]byte{byte(i)}, maxGossipPacketSize))
+	}
+
+	for i := 0; i < 10; i++ {
+		select {
+		case b := <-delivered:
+			require.True(t, bytes.Contains(b, bytes.Repeat([]byte{byte(i)}, maxGossipPacketSize)))
+		case <-time.After(time.Second):
+			t.Fatalf("message %d not delivered to the healthy peer", i)
+		}
+	}
+	require.Zero(t, testutil.ToFloat64(c.messages.WithLabelValues(pathReliable, "dropped")))
+}
+
 func newChannel(
 	t *testing.T,
 	send func([]byte),
@@ -24,6 +24,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/f1shl3gs/gossiping/cluster/clusterpb"
 	"github.com/hashicorp/memberlist"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
@@ -50,6 +51,13 @@ type Peer struct {
 
 	knownPeers    []string
 	advertiseAddr string
+	clusterName   string
+	keyring       *Keyring
+	encoding      clusterpb.Encoding
+	evictions     *Channel
+
+	// the health score of memberlist saturates at it
+	maxHealthScore int
 
 	failedReconnectionsCounter prometheus.Counter
 	reconnectionsCounter       prometheus.Counter
@@ -61,6 +69,13 @@ type Peer struct {
 
 	logger  *zap.Logger
 	changes chan struct{}
+
+	metaMtx  sync.RWMutex
+	nodeMeta Meta
+	meta     []byte
+
+	subsMtx sync.Mutex
+	subs    map[*Subscription]struct{}
 }
 
 // peer is an internal type used for bookkeeping. It holds the state of peers
@@ -104,6 +119,10 @@ const (
 	DefaultReconnectTimeout  = 6 * time.Hour
 	DefaultRefreshInterval   = 15 * time.Second
 	maxGossipPacketSize      = 1400
+
+	// updateNodeTimeout bounds the wait of the metadata of the node
+	// being gossiped
+	updateNodeTimeout = 10 * time.Second
 )
 
 func Create(
@@ -111,14 +130,21 @@ func Create(
 	reg prometheus.Registerer,
 	bindAddr string,
 	advertiseAddr string,
+	clusterName string,
+	labels map[string]string,
+	keyring *Keyring,
+	tlsConf *TLSConfig,
 	knownPeers []string,
 	waitIfEmpty bool,
-	pushPullInterval time.Duration,
-	gossipInterval time.Duration,
-	tcpTimeout time.Duration,
-	probeTimeout time.Duration,
-	probeInterval time.Duration,
+	preset Preset,
+	timings Timings,
+	compression Compression,
 ) (*Peer, error) {
+	encoding, err := compression.Encoding()
+	if err != nil {
+		return nil, err
+	}
+
 	bindHost, bindPortStr, err := net.SplitHostPort(bindAddr)
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid listen address")
@@ -174,7 +200,22 @@ func Create(
 		return nil, err
 	}
 
+	nodeMeta := Meta{Cluster: clusterName, Labels: labels, Protocol: protocolVersion}
+	if keyring != nil {
+		nodeMeta.Keys = keyring.fingerprints()
+	}
+
+	meta, err := nodeMeta.encode()
+	if err != nil {
+		return nil, err
+	}
+
 	p := &Peer{
+		clusterName:   clusterName,
+		keyring:       keyring,
+		nodeMeta:      nodeMeta,
+		meta:          meta,
+		encoding:      encoding,
 		states:        map[string]State{},
 		stopc:         make(chan struct{}),
 		readyc:        make(chan struct{}),
@@ -182,7 +223,8 @@ func Create(
 		peers:         map[string]peer{},
 		resolvedPeers: resolvedPeers,
 		knownPeers:    knownPeers,
-		changes:       make(chan struct{}, 16),
+		changes:       make(chan struct{}, 1),
+		subs:          make(map[*Subscription]struct{}),
 	}
 
 	p.register(reg, name.String())
@@ -192,8 +234,9 @@ func Create(
 		retransmit = 3
 	}
 	p.delegate = newDelegate(l, reg, p, retransmit)
+	p.evictions = p.AddState("evictions", evictions{p: p}, reg)
 
-	cfg := memberlist.DefaultLANConfig()
+	cfg := preset.config()
 	cfg.Name = name.String()
 	cfg.BindAddr = bindHost
 	cfg.BindPort = bindPort
@@ -201,15 +244,29 @@ func Create(
 	cfg.Ping = p.delegate
 	cfg.Alive = p.delegate
 	cfg.Events = p.delegate
-	cfg.GossipInterval = gossipInterval
-	cfg.PushPullInterval = pushPullInterval
-	cfg.TCPTimeout = tcpTimeout
-	cfg.ProbeTimeout = probeTimeout
-	cfg.ProbeInterval = probeInterval
+	cfg.Merge = p.delegate
+	cfg.GossipInterval = timings.GossipInterval
+	cfg.PushPullInterval = timings.PushPullInterval
+	cfg.TCPTimeout = timings.TCPTimeout
+	cfg.ProbeTimeout = timings.ProbeTimeout
+	cfg.ProbeInterval = timings.ProbeInterval
 	cfg.LogOutput = &logWriter{l: l}
 	cfg.GossipNodes = retransmit
 	cfg.UDPBufferSize = maxGossipPacketSize
 
+	if keyring != nil {
+		cfg.Keyring = keyring.keyring
+	}
+
+	if tlsConf != nil {
+		transport, err := NewTLSTransport(l, reg, *tlsConf, bindHost, bindPort, timings.TCPTimeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "create tls transport")
+		}
+
+		cfg.Transport = transport
+	}
+
 	if advertiseHost != "" {
 		cfg.AdvertiseAddr = advertiseHost
 		cfg.AdvertisePort = advertisePort
@@ -218,14 +275,83 @@ func Create(
 		p.setInitialFailed(resolvedPeers, bindAddr)
 	}
 
+	p.maxHealthScore = cfg.AwarenessMaxMultiplier - 1
+
 	ml, err := memberlist.Create(cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, "create memberlist")
 	}
 	p.mlist = ml
+
+	if keyring != nil {
+		// the callback runs with the keyring locked, and it might be
+		// called by memberlist
+		keyring.notify(func() {
+			go p.refreshKeys()
+		})
+	}
+
 	return p, nil
 }
 
+// refreshKeys updates the fingerprints of the keys in the metadata of the
+// node, and gossips it
+func (p *Peer) refreshKeys() {
+	p.metaMtx.Lock()
+	nodeMeta := p.nodeMeta
+	nodeMeta.Keys = p.keyring.fingerprints()
+	meta, err := nodeMeta.encode()
+	if err == nil {
+		p.nodeMeta = nodeMeta
+		p.meta = meta
+	}
+	p.metaMtx.Unlock()
+
+	if err != nil {
+		p.logger.Warn("update node meta failed",
+			zap.Error(err))
+		return
+	}
+
+	err = p.mlist.UpdateNode(updateNodeTimeout)
+	if err != nil {
+		p.logger.Warn("gossip node meta failed",
+			zap.Error(err))
+	}
+}
+
+// KeyStates returns whether every alive member has the key installed,
+// along with the state of each of them.
+func (p *Peer) KeyStates(key []byte) ([]KeyState, bool) {
+	fingerprint := Fingerprint(key)
+	self := p.Name()
+
+	all := true
+	var states []KeyState
+	for _, n := range p.Peers() {
+		state := KeyState{Node: n.Name}
+		if n.Name == self {
+			state.Installed = p.keyring != nil && p.keyring.installed(key)
+		} else if meta, err := NodeMeta(n); err == nil {
+			for _, fp := range meta.Keys {
+				if fp == fingerprint {
+					state.Installed = true
+					break
+				}
+			}
+		}
+
+		all = all && state.Installed
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Node < states[j].Node
+	})
+
+	return states, all
+}
+
 func (p *Peer) Join(
 	reconnectInterval time.Duration,
 	reconnectTimeout time.Duration) error {
@@ -463,10 +589,22 @@ func (p *Peer) refresh() {
 	}
 }
 
+// Changed returns a channel notified once any peer changes, the changes
+// made before the notification is received are coalesced into it, use
+// Subscribe to receive every change.
 func (p *Peer) Changed() <-chan struct{} {
 	return p.changes
 }
 
+// changed notifies Changed, it never blocks since a pending notification
+// covers this change too
+func (p *Peer) changed() {
+	select {
+	case p.changes <- struct{}{}:
+	default:
+	}
+}
+
 func (p *Peer) peerJoin(n *memberlist.Node) {
 	p.peerLock.Lock()
 	defer p.peerLock.Unlock()
@@ -495,11 +633,8 @@ func (p *Peer) peerJoin(n *memberlist.Node) {
 		p.failedPeers = removeOldPeer(p.failedPeers, pr.Address())
 	}
 
-	select {
-	case p.changes <- struct{}{}:
-	default:
-		p.logger.Warn("peer join event dropped")
-	}
+	p.observe(PeerJoin, n)
+	p.changed()
 }
 
 func (p *Peer) peerLeave(n *memberlist.Node) {
@@ -523,11 +658,8 @@ func (p *Peer) peerLeave(n *memberlist.Node) {
 		zap.String("peer", pr.Name),
 		zap.String("address", pr.Address()))
 
-	select {
-	case p.changes <- struct{}{}:
-	default:
-		p.logger.Warn("peer left event dropped")
-	}
+	p.observe(PeerLeave, n)
+	p.changed()
 }
 
 func (p *Peer) peerUpdate(n *memberlist.Node) {
@@ -549,11 +681,8 @@ func (p *Peer) peerUpdate(n *memberlist.Node) {
 		zap.String("peer", pr.Name),
 		zap.String("address", pr.Address()))
 
-	select {
-	case p.changes <- struct{}{}:
-	default:
-		p.logger.Warn("peer update event dropped")
-	}
+	p.observe(PeerUpdate, n)
+	p.changed()
 }
 
 // AddState adds a new state that will be gossiped. It returns a channel to which
@@ -565,12 +694,12 @@ func (p *Peer) AddState(key string, s State, reg prometheus.Registerer) *Channel
 	}
 	peers := func() []*memberlist.Node {
 		nodes := p.Peers()
-		// for i, n := range nodes {
-		// 	if n.Name == p.Self().Name {
-		// 		nodes = append(nodes[:i], nodes[i+1:]...)
-		// 		break
-		// 	}
-		// }
+		for i, n := range nodes {
+			if n.Name == p.Self().Name {
+				nodes = append(nodes[:i], nodes[i+1:]...)
+				break
+			}
+		}
 		return nodes
 	}
 	sendOversize := func(n *memberlist.Node, b []byte) error {
@@ -620,6 +749,18 @@ func (p *Peer) Status() string {
 	return "settling"
 }
 
+// HealthScore returns the health score of memberlist, lower is better
+// and zero means totally healthy.
+func (p *Peer) HealthScore() int {
+	return p.mlist.GetHealthScore()
+}
+
+// Healthy returns false if the health score saturates, the node keeps
+// failing to probe others and is likely partitioned from them.
+func (p *Peer) Healthy() bool {
+	return p.HealthScore() < p.maxHealthScore
+}
+
 // Info returns a JSON-serializable dump of cluster state.
 // Useful for debug.
 func (p *Peer) Info() map[string]interface{} {
@@ -718,6 +859,19 @@ type State interface {
 	Merge(b []byte) error
 }
 
+// DeltaState is a State synced by digests, push/pull exchanges the digests
+// only, and each side sends the other the entries it lacks or has older.
+type DeltaState interface {
+	State
+
+	// Digest returns the versions of the entries of the state.
+	Digest() ([]byte, error)
+
+	// Delta returns the entries missing or outdated in the digest, in the
+	// format Merge accepts, nil if there are none.
+	Delta(digest []byte) ([]byte, error)
+}
+
 // We use a simple broadcast implementation in which items are never invalidated by others.
 type simpleBroadcast []byte
 
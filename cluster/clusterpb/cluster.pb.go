@@ -24,9 +24,42 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+// Encoding is the compression of the data of parts
+type Encoding int32
+
+const (
+	Encoding_NONE   Encoding = 0
+	Encoding_SNAPPY Encoding = 1
+	Encoding_ZSTD   Encoding = 2
+)
+
+var Encoding_name = map[int32]string{
+	0: "NONE",
+	1: "SNAPPY",
+	2: "ZSTD",
+}
+
+var Encoding_value = map[string]int32{
+	"NONE":   0,
+	"SNAPPY": 1,
+	"ZSTD":   2,
+}
+
+func (x Encoding) String() string {
+	return proto.EnumName(Encoding_name, int32(x))
+}
+
+func (Encoding) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_3cfb3b8ec240c376, []int{0}
+}
+
 type Part struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Data                 []byte   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Key  string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// data is the digest of the state, the receiver replies the entries
+	// the sender lacks
+	Digest               bool     `protobuf:"varint,3,opt,name=digest,proto3" json:"digest,omitempty"`
+	Encoding             Encoding `protobuf:"varint,4,opt,name=encoding,proto3,enum=clusterpb.Encoding" json:"encoding,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -66,7 +99,9 @@ func (m *Part) XXX_DiscardUnknown() {
 var xxx_messageInfo_Part proto.InternalMessageInfo
 
 type FullState struct {
-	Parts                []Part   `protobuf:"bytes,1,rep,name=parts,proto3" json:"parts"`
+	Parts []Part `protobuf:"bytes,1,rep,name=parts,proto3" json:"parts"`
+	// name of the node the state comes from
+	From                 string   `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -106,6 +141,7 @@ func (m *FullState) XXX_DiscardUnknown() {
 var xxx_messageInfo_FullState proto.InternalMessageInfo
 
 func init() {
+	proto.RegisterEnum("clusterpb.Encoding", Encoding_name, Encoding_value)
 	proto.RegisterType((*Part)(nil), "clusterpb.Part")
 	proto.RegisterType((*FullState)(nil), "clusterpb.FullState")
 }
@@ -113,18 +149,24 @@ func init() {
 func init() { proto.RegisterFile("cluster.proto", fileDescriptor_3cfb3b8ec240c376) }
 
 var fileDescriptor_3cfb3b8ec240c376 = []byte{
-	// 168 bytes of a gzipped FileDescriptorProto
+	// 260 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4d, 0xce, 0x29, 0x2d,
 	0x2e, 0x49, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x84, 0x72, 0x0b, 0x92, 0xa4,
-	0x44, 0xd2, 0xf3, 0xd3, 0xf3, 0xc1, 0xa2, 0xfa, 0x20, 0x16, 0x44, 0x81, 0x92, 0x0e, 0x17, 0x4b,
+	0x44, 0xd2, 0xf3, 0xd3, 0xf3, 0xc1, 0xa2, 0xfa, 0x20, 0x16, 0x44, 0x81, 0x52, 0x29, 0x17, 0x4b,
 	0x40, 0x62, 0x51, 0x89, 0x90, 0x00, 0x17, 0x73, 0x76, 0x6a, 0xa5, 0x04, 0xa3, 0x02, 0xa3, 0x06,
 	0x67, 0x10, 0x88, 0x29, 0x24, 0xc4, 0xc5, 0x92, 0x92, 0x58, 0x92, 0x28, 0xc1, 0xa4, 0xc0, 0xa8,
-	0xc1, 0x13, 0x04, 0x66, 0x2b, 0x59, 0x70, 0x71, 0xba, 0x95, 0xe6, 0xe4, 0x04, 0x97, 0x24, 0x96,
-	0xa4, 0x0a, 0x69, 0x73, 0xb1, 0x16, 0x24, 0x16, 0x95, 0x14, 0x4b, 0x30, 0x2a, 0x30, 0x6b, 0x70,
-	0x1b, 0xf1, 0xeb, 0xc1, 0xed, 0xd2, 0x03, 0x19, 0xe9, 0xc4, 0x72, 0xe2, 0x9e, 0x3c, 0x43, 0x10,
-	0x44, 0x8d, 0x93, 0xc0, 0x89, 0x87, 0x72, 0x0c, 0x27, 0x1e, 0xc9, 0x31, 0x5e, 0x78, 0x24, 0xc7,
-	0xf8, 0xe0, 0x91, 0x1c, 0x63, 0x12, 0x1b, 0xd8, 0x01, 0xc6, 0x80, 0x00, 0x00, 0x00, 0xff, 0xff,
-	0xfd, 0x3c, 0xdb, 0xe7, 0xb2, 0x00, 0x00, 0x00,
+	0xc1, 0x13, 0x04, 0x66, 0x0b, 0x89, 0x71, 0xb1, 0xa5, 0x64, 0xa6, 0xa7, 0x16, 0x97, 0x48, 0x30,
+	0x2b, 0x30, 0x6a, 0x70, 0x04, 0x41, 0x79, 0x42, 0xfa, 0x5c, 0x1c, 0xa9, 0x79, 0xc9, 0xf9, 0x29,
+	0x99, 0x79, 0xe9, 0x12, 0x2c, 0x0a, 0x8c, 0x1a, 0x7c, 0x46, 0xc2, 0x7a, 0x70, 0x9b, 0xf5, 0x5c,
+	0xa1, 0x52, 0x41, 0x70, 0x45, 0x4a, 0x3e, 0x5c, 0x9c, 0x6e, 0xa5, 0x39, 0x39, 0xc1, 0x25, 0x89,
+	0x25, 0xa9, 0x42, 0xda, 0x5c, 0xac, 0x05, 0x89, 0x45, 0x25, 0xc5, 0x12, 0x8c, 0x0a, 0xcc, 0x1a,
+	0xdc, 0x46, 0xfc, 0x48, 0x5a, 0x41, 0x6e, 0x73, 0x62, 0x39, 0x71, 0x4f, 0x9e, 0x21, 0x08, 0xa2,
+	0x06, 0xe4, 0xac, 0xb4, 0xa2, 0xfc, 0x5c, 0xb0, 0xb3, 0x38, 0x83, 0xc0, 0x6c, 0x2d, 0x2d, 0x2e,
+	0x0e, 0x98, 0x1d, 0x42, 0x1c, 0x5c, 0x2c, 0x7e, 0xfe, 0x7e, 0xae, 0x02, 0x0c, 0x42, 0x5c, 0x5c,
+	0x6c, 0xc1, 0x7e, 0x8e, 0x01, 0x01, 0x91, 0x02, 0x8c, 0x20, 0xd1, 0xa8, 0xe0, 0x10, 0x17, 0x01,
+	0x26, 0x27, 0x81, 0x13, 0x0f, 0xe5, 0x18, 0x4e, 0x3c, 0x92, 0x63, 0xbc, 0xf0, 0x48, 0x8e, 0xf1,
+	0xc1, 0x23, 0x39, 0xc6, 0x24, 0x36, 0x70, 0x48, 0x18, 0x03, 0x06, 0x00, 0xcf, 0x64, 0x4a, 0x34,
+	0x3b, 0x01, 0x00, 0x00,
 }
 
 func (m *Part) Marshal() (dAtA []byte, err error) {
@@ -151,6 +193,21 @@ func (m *Part) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Encoding != 0 {
+		i = encodeVarintCluster(dAtA, i, uint64(m.Encoding))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Digest {
+		i--
+		if m.Digest {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.Data) > 0 {
 		i -= len(m.Data)
 		copy(dAtA[i:], m.Data)
@@ -192,6 +249,13 @@ func (m *FullState) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.From) > 0 {
+		i -= len(m.From)
+		copy(dAtA[i:], m.From)
+		i = encodeVarintCluster(dAtA, i, uint64(len(m.From)))
+		i--
+		dAtA[i] = 0x12
+	}
 	if len(m.Parts) > 0 {
 		for iNdEx := len(m.Parts) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -234,6 +298,12 @@ func (m *Part) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovCluster(uint64(l))
 	}
+	if m.Digest {
+		n += 2
+	}
+	if m.Encoding != 0 {
+		n += 1 + sovCluster(uint64(m.Encoding))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -252,6 +322,10 @@ func (m *FullState) Size() (n int) {
 			n += 1 + l + sovCluster(uint64(l))
 		}
 	}
+	l = len(m.From)
+	if l > 0 {
+		n += 1 + l + sovCluster(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -359,16 +433,52 @@ func (m *Part) Unmarshal(dAtA []byte) error {
 				m.Data = []byte{}
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Digest", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCluster
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Digest = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Encoding", wireType)
+			}
+			m.Encoding = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCluster
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Encoding |= Encoding(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCluster(dAtA[iNdEx:])
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthCluster
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthCluster
 			}
 			if (iNdEx + skippy) > l {
@@ -447,16 +557,45 @@ func (m *FullState) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field From", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCluster
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthCluster
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCluster
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.From = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCluster(dAtA[iNdEx:])
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthCluster
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthCluster
 			}
 			if (iNdEx + skippy) > l {
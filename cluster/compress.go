@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"github.com/f1shl3gs/gossiping/cluster/clusterpb"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Compression of the states exchanged by push/pull and of the deltas
+type Compression string
+
+const (
+	CompressionNone   Compression = "none"
+	CompressionSnappy Compression = "snappy"
+	CompressionZstd   Compression = "zstd"
+)
+
+// maxDecodedSize bounds the memory decoding a part may take
+const maxDecodedSize = 64 << 20
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecodedSize))
+)
+
+// Encoding returns the encoding of parts, empty means none
+func (c Compression) Encoding() (clusterpb.Encoding, error) {
+	switch c {
+	case "", CompressionNone:
+		return clusterpb.Encoding_NONE, nil
+	case CompressionSnappy:
+		return clusterpb.Encoding_SNAPPY, nil
+	case CompressionZstd:
+		return clusterpb.Encoding_ZSTD, nil
+	default:
+		return 0, errors.Errorf("unknown compression %q", c)
+	}
+}
+
+func compress(enc clusterpb.Encoding, b []byte) ([]byte, error) {
+	switch enc {
+	case clusterpb.Encoding_NONE:
+		return b, nil
+	case clusterpb.Encoding_SNAPPY:
+		return s2.EncodeSnappy(nil, b), nil
+	case clusterpb.Encoding_ZSTD:
+		return zstdEncoder.EncodeAll(b, nil), nil
+	default:
+		return nil, errors.Errorf("unknown encoding %d", enc)
+	}
+}
+
+func decompress(enc clusterpb.Encoding, b []byte) ([]byte, error) {
+	switch enc {
+	case clusterpb.Encoding_NONE:
+		return b, nil
+	case clusterpb.Encoding_SNAPPY:
+		n, err := s2.DecodedLen(b)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxDecodedSize {
+			return nil, errors.Errorf("decoded size %d exceeds the limit", n)
+		}
+
+		return s2.Decode(nil, b)
+	case clusterpb.Encoding_ZSTD:
+		return zstdDecoder.DecodeAll(b, nil)
+	default:
+		return nil, errors.Errorf("unknown encoding %d", enc)
+	}
+}
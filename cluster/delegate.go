@@ -28,6 +28,7 @@ const (
 	maxQueueSize = 4096
 	fullState    = "full_state"
 	update       = "update"
+	deltaUpdate  = "delta"
 )
 
 // delegate implements memberlist.Delegate and memberlist.EventDelegate
@@ -45,6 +46,7 @@ type delegate struct {
 	messagesPruned       prometheus.Counter
 	nodeAlive            *prometheus.CounterVec
 	nodePingDuration     *prometheus.HistogramVec
+	peersRejected        prometheus.Counter
 }
 
 func newDelegate(l *zap.Logger, reg prometheus.Registerer, p *Peer, retransmit int) *delegate {
@@ -108,6 +110,11 @@ func newDelegate(l *zap.Logger, reg prometheus.Registerer, p *Peer, retransmit i
 	}, []string{"peer"},
 	)
 
+	peersRejected := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gossiping_cluster_peers_rejected_total",
+		Help: "Total number of peers rejected since they belong to other clusters.",
+	})
+
 	messagesReceived.WithLabelValues(fullState)
 	messagesReceivedSize.WithLabelValues(fullState)
 	messagesReceived.WithLabelValues(update)
@@ -116,10 +123,12 @@ func newDelegate(l *zap.Logger, reg prometheus.Registerer, p *Peer, retransmit i
 	messagesSentSize.WithLabelValues(fullState)
 	messagesSent.WithLabelValues(update)
 	messagesSentSize.WithLabelValues(update)
+	messagesSent.WithLabelValues(deltaUpdate)
+	messagesSentSize.WithLabelValues(deltaUpdate)
 
 	reg.MustRegister(messagesReceived, messagesReceivedSize, messagesSent, messagesSentSize,
 		gossipClusterMembers, peerPosition, healthScore, messagesQueued, messagesPruned,
-		nodeAlive, nodePingDuration,
+		nodeAlive, nodePingDuration, peersRejected,
 	)
 
 	d := &delegate{
@@ -133,6 +142,7 @@ func newDelegate(l *zap.Logger, reg prometheus.Registerer, p *Peer, retransmit i
 		messagesPruned:       messagesPruned,
 		nodeAlive:            nodeAlive,
 		nodePingDuration:     nodePingDuration,
+		peersRejected:        peersRejected,
 	}
 
 	go d.handleQueueDepth()
@@ -142,7 +152,10 @@ func newDelegate(l *zap.Logger, reg prometheus.Registerer, p *Peer, retransmit i
 
 // NodeMeta retrieves meta-data about the current node when broadcasting an alive message.
 func (d *delegate) NodeMeta(limit int) []byte {
-	return []byte{}
+	d.metaMtx.RLock()
+	defer d.metaMtx.RUnlock()
+
+	return d.meta
 }
 
 // NotifyMsg is the callback invoked when a user-level gossip message is received.
@@ -161,7 +174,14 @@ func (d *delegate) NotifyMsg(b []byte) {
 	if !ok {
 		return
 	}
-	if err := s.Merge(p.Data); err != nil {
+	data, err := decompress(p.Encoding, p.Data)
+	if err != nil {
+		d.logger.Warn("decompress broadcast failed",
+			zap.String("key", p.Key),
+			zap.Error(err))
+		return
+	}
+	if err := s.Merge(data); err != nil {
 		d.logger.Warn("merge broadcast",
 			zap.String("key", p.Key),
 			zap.Error(err))
@@ -179,21 +199,27 @@ func (d *delegate) GetBroadcasts(overhead, limit int) [][]byte {
 	return msgs
 }
 
-// LocalState is called when gossip fetches local state.
-func (d *delegate) LocalState(_ bool) []byte {
+// LocalState is called when gossip fetches local state, delta states are
+// represented by their digests. Nodes of older versions would merge the
+// digests and the compressed states as the full states, so the full state
+// is sent as it is if any member is of them, or the remote node is joining,
+// whose version is unknown yet.
+func (d *delegate) LocalState(join bool) []byte {
 	all := &clusterpb.FullState{
 		Parts: make([]clusterpb.Part, 0, len(d.states)),
+		From:  d.Name(),
 	}
 
+	legacy := join || !digestSupported(d.Peers())
 	for key, s := range d.states {
-		b, err := s.MarshalBinary()
+		part, err := d.localPart(key, s, legacy)
 		if err != nil {
 			d.logger.Warn("encode local state failed",
 				zap.String("key", key),
 				zap.Error(err))
 			return nil
 		}
-		all.Parts = append(all.Parts, clusterpb.Part{Key: key, Data: b})
+		all.Parts = append(all.Parts, part)
 	}
 	b, err := proto.Marshal(all)
 	if err != nil {
@@ -206,6 +232,34 @@ func (d *delegate) LocalState(_ bool) []byte {
 	return b
 }
 
+func (d *delegate) localPart(key string, s State, legacy bool) (clusterpb.Part, error) {
+	var (
+		b    []byte
+		err  error
+		part = clusterpb.Part{Key: key}
+	)
+
+	ds, ok := s.(DeltaState)
+	if ok && !legacy {
+		part.Digest = true
+		b, err = ds.Digest()
+	} else {
+		b, err = s.MarshalBinary()
+	}
+	if err != nil || len(b) == 0 {
+		return part, err
+	}
+
+	if legacy {
+		part.Data = b
+		return part, nil
+	}
+
+	part.Encoding = d.encoding
+	part.Data, err = compress(d.encoding, b)
+	return part, err
+}
+
 func (d *delegate) MergeRemoteState(buf []byte, _ bool) {
 	d.messagesReceived.WithLabelValues(fullState).Inc()
 	d.messagesReceivedSize.WithLabelValues(fullState).Add(float64(len(buf)))
@@ -226,7 +280,18 @@ func (d *delegate) MergeRemoteState(buf []byte, _ bool) {
 				zap.Int("len", len(buf)))
 			continue
 		}
-		if err := s.Merge(p.Data); err != nil {
+		data, err := decompress(p.Encoding, p.Data)
+		if err != nil {
+			d.logger.Warn("decompress remote state failed",
+				zap.String("key", p.Key),
+				zap.Error(err))
+			return
+		}
+		if p.Digest {
+			d.replyDelta(fs.From, p.Key, s, data)
+			continue
+		}
+		if err := s.Merge(data); err != nil {
 			d.logger.Warn("merge remote state",
 				zap.String("key", p.Key),
 				zap.Error(err))
@@ -235,6 +300,75 @@ func (d *delegate) MergeRemoteState(buf []byte, _ bool) {
 	}
 }
 
+// replyDelta sends the entries the remote node lacks according to its
+// digest, they are merged by NotifyMsg of it.
+func (d *delegate) replyDelta(from, key string, s State, digest []byte) {
+	ds, ok := s.(DeltaState)
+	if !ok {
+		d.logger.Warn("digest of state without delta",
+			zap.String("key", key),
+			zap.String("from", from))
+		return
+	}
+
+	delta, err := ds.Delta(digest)
+	if err != nil {
+		d.logger.Warn("compute delta failed",
+			zap.String("key", key),
+			zap.String("from", from),
+			zap.Error(err))
+		return
+	}
+	if len(delta) == 0 {
+		return
+	}
+
+	data, err := compress(d.encoding, delta)
+	if err != nil {
+		d.logger.Warn("compress delta failed",
+			zap.String("key", key),
+			zap.Error(err))
+		return
+	}
+	b, err := proto.Marshal(&clusterpb.Part{Key: key, Data: data, Encoding: d.encoding})
+	if err != nil {
+		d.logger.Warn("encode delta failed",
+			zap.String("key", key),
+			zap.Error(err))
+		return
+	}
+
+	// push/pull holds the stream till the merging is done, so send it
+	// over another one
+	go func() {
+		var node *memberlist.Node
+		for _, n := range d.mlist.Members() {
+			if n.Name == from {
+				node = n
+				break
+			}
+		}
+		if node == nil {
+			d.logger.Warn("unknown node of digest",
+				zap.String("key", key),
+				zap.String("from", from))
+			return
+		}
+
+		err := d.mlist.SendReliable(node, b)
+		if err != nil {
+			d.logger.Warn("send delta failed",
+				zap.String("key", key),
+				zap.String("node", node.Address()),
+				zap.Error(err))
+			return
+		}
+
+		d.messagesSent.WithLabelValues(deltaUpdate).Inc()
+		d.messagesSentSize.WithLabelValues(deltaUpdate).Add(float64(len(b)))
+	}()
+}
+
 // NotifyJoin is called if a peer joins the cluster.
 func (d *delegate) NotifyJoin(n *memberlist.Node) {
 	d.logger.Debug("notify join",
@@ -261,10 +395,42 @@ func (d *delegate) NotifyUpdate(n *memberlist.Node) {
 
 // NotifyAlive implements the memberlist.AliveDelegate interface.
 func (d *delegate) NotifyAlive(peer *memberlist.Node) error {
+	err := d.checkCluster(peer)
+	if err != nil {
+		return err
+	}
+
 	d.nodeAlive.WithLabelValues(peer.Name).Inc()
 	return nil
 }
 
+// NotifyMerge implements the memberlist.MergeDelegate interface, the
+// push/pull with nodes of other clusters is aborted, so neither their
+// members nor their states are merged.
+func (d *delegate) NotifyMerge(peers []*memberlist.Node) error {
+	for _, peer := range peers {
+		err := d.checkCluster(peer)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *delegate) checkCluster(peer *memberlist.Node) error {
+	err := checkCluster(d.clusterName, peer)
+	if err != nil {
+		d.peersRejected.Inc()
+		d.logger.Warn("reject peer of another cluster",
+			zap.String("peer", peer.Name),
+			zap.String("addr", peer.Address()),
+			zap.Error(err))
+	}
+
+	return err
+}
+
 // AckPayload implements the memberlist.PingDelegate interface.
 func (d *delegate) AckPayload() []byte {
 	return []byte{}
@@ -0,0 +1,176 @@
+package cluster
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/cluster/clusterpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/memberlist"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// versions is a DeltaState of names and their versions
+type versions struct {
+	mtx sync.Mutex
+	m   map[string]int64
+
+	// merged counts the entries received
+	merged int
+}
+
+func (v *versions) MarshalBinary() ([]byte, error) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	return json.Marshal(v.m)
+}
+
+func (v *versions) Merge(b []byte) error {
+	var m map[string]int64
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	for name, ver := range m {
+		v.merged++
+		if ver > v.m[name] {
+			v.m[name] = ver
+		}
+	}
+
+	return nil
+}
+
+func (v *versions) Digest() ([]byte, error) {
+	return v.MarshalBinary()
+}
+
+func (v *versions) Delta(b []byte) ([]byte, error) {
+	var digest map[string]int64
+	if err := json.Unmarshal(b, &digest); err != nil {
+		return nil, err
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	delta := map[string]int64{}
+	for name, ver := range v.m {
+		if remote, ok := digest[name]; !ok || ver > remote {
+			delta[name] = ver
+		}
+	}
+	if len(delta) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(delta)
+}
+
+func (v *versions) get() (map[string]int64, int) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	m := make(map[string]int64, len(v.m))
+	for name, ver := range v.m {
+		m[name] = ver
+	}
+
+	return m, v.merged
+}
+
+// set replaces the entries of the state, and resets the count of merged
+func (v *versions) set(m map[string]int64) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	v.m = m
+	v.merged = 0
+}
+
+func TestDeltaStateSync(t *testing.T) {
+	for _, enc := range []clusterpb.Encoding{clusterpb.Encoding_NONE, clusterpb.Encoding_SNAPPY, clusterpb.Encoding_ZSTD} {
+		t.Run(enc.String(), func(t *testing.T) {
+			a := newTestPeer(t)
+			defer a.Leave(time.Second)
+			a.encoding = enc
+			sa := &versions{m: map[string]int64{}}
+			a.AddState("versions", sa, prometheus.NewRegistry())
+
+			b := newTestPeer(t)
+			defer b.Leave(time.Second)
+			b.encoding = enc
+			sb := &versions{m: map[string]int64{}}
+			b.AddState("versions", sb, prometheus.NewRegistry())
+
+			_, err := b.mlist.Join([]string{a.Self().Address()})
+			require.NoError(t, err)
+
+			// a periodic push/pull
+			sa.set(map[string]int64{"same": 1, "newer": 2, "older": 1, "missing": 1})
+			sb.set(map[string]int64{"same": 1, "newer": 1, "older": 2, "extra": 1})
+			a.delegate.MergeRemoteState(b.delegate.LocalState(false), false)
+			b.delegate.MergeRemoteState(a.delegate.LocalState(false), false)
+
+			want := map[string]int64{"same": 1, "newer": 2, "older": 2, "missing": 1, "extra": 1}
+			require.Eventually(t, func() bool {
+				ma, _ := sa.get()
+				mb, _ := sb.get()
+				return len(ma) == len(want) && len(mb) == len(want)
+			}, 5*time.Second, 10*time.Millisecond)
+
+			ma, merged := sa.get()
+			require.Equal(t, want, ma)
+			require.Equal(t, 2, merged)
+			mb, merged := sb.get()
+			require.Equal(t, want, mb)
+			require.Equal(t, 2, merged)
+		})
+	}
+}
+
+func TestLocalStateLegacy(t *testing.T) {
+	a := newTestPeer(t)
+	defer a.Leave(time.Second)
+	a.encoding = clusterpb.Encoding_ZSTD
+	sa := &versions{m: map[string]int64{"job": 1}}
+	a.AddState("versions", sa, prometheus.NewRegistry())
+
+	localPart := func(join bool) clusterpb.Part {
+		var fs clusterpb.FullState
+		require.NoError(t, proto.Unmarshal(a.delegate.LocalState(join), &fs))
+		for _, part := range fs.Parts {
+			if part.Key == "versions" {
+				return part
+			}
+		}
+		t.Fatal("state not found")
+		return clusterpb.Part{}
+	}
+
+	part := localPart(false)
+	require.True(t, part.Digest)
+	require.Equal(t, clusterpb.Encoding_ZSTD, part.Encoding)
+
+	// the version of joining nodes is unknown
+	full, err := sa.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, clusterpb.Part{Key: "versions", Data: full}, localPart(true))
+
+	meta := func(m Meta) *memberlist.Node {
+		b, err := m.encode()
+		require.NoError(t, err)
+		return &memberlist.Node{Meta: b}
+	}
+	current := meta(Meta{Protocol: protocolVersion})
+	require.True(t, digestSupported([]*memberlist.Node{current, current}))
+	require.False(t, digestSupported([]*memberlist.Node{current, meta(Meta{})}))
+	require.False(t, digestSupported([]*memberlist.Node{current, {}}))
+}
@@ -0,0 +1,281 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Operations on the keyring
+const (
+	KeyInstall = "install"
+	KeyUse     = "use"
+	KeyRemove  = "remove"
+)
+
+// KeyOp is an operation on the keyring, Key is encoded in base64
+type KeyOp struct {
+	Op  string `json:"op"`
+	Key string `json:"key"`
+}
+
+// KeyInfo identifies a key without revealing it
+type KeyInfo struct {
+	Fingerprint string `json:"fingerprint"`
+	Primary     bool   `json:"primary"`
+}
+
+// KeyState tells whether a member has a key installed
+type KeyState struct {
+	Node      string `json:"node"`
+	Installed bool   `json:"installed"`
+}
+
+// Fingerprint returns the first 8 bytes of the SHA-256 of the key in hex
+func Fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Keyring holds the keys used to encrypt gossip, the first key encrypts
+// outgoing messages and all keys are tried to decrypt incoming messages.
+// It implements State, so operations broadcast through its Channel are
+// applied by every node, and keys can be rotated online by installing
+// the new key, using it, and then removing the old one.
+type Keyring struct {
+	logger  *zap.Logger
+	keyring *memberlist.Keyring
+
+	// keys are saved to path on changes, if it's not empty
+	mtx  sync.Mutex
+	path string
+
+	// onChange is called once the keys changed
+	onChange func()
+}
+
+// DecodeKey decodes a base64 encoded key, which must be 16, 24 or 32
+// bytes to select AES-128, AES-192 or AES-256.
+func DecodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.Wrap(err, "decode key")
+	}
+
+	err = memberlist.ValidateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// LoadKeys reads base64 encoded keys from the file, one key per line,
+// the first one is the primary key.
+func LoadKeys(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keys = append(keys, line)
+	}
+
+	return keys, scanner.Err()
+}
+
+// NewKeyring creates a Keyring of the base64 encoded keys, the first one
+// is the primary key. Changes are saved to path if it's not empty.
+func NewKeyring(logger *zap.Logger, keys []string, path string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	decoded := make([][]byte, 0, len(keys))
+	for _, s := range keys {
+		key, err := DecodeKey(s)
+		if err != nil {
+			return nil, err
+		}
+
+		decoded = append(decoded, key)
+	}
+
+	keyring, err := memberlist.NewKeyring(decoded, decoded[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keyring{
+		logger:  logger,
+		keyring: keyring,
+		path:    path,
+	}, nil
+}
+
+// Keys returns the base64 encoded keys, the primary key is the first one
+func (k *Keyring) Keys() []string {
+	primary := k.keyring.GetPrimaryKey()
+	keys := []string{base64.StdEncoding.EncodeToString(primary)}
+	for _, key := range k.keyring.GetKeys() {
+		if bytes.Equal(key, primary) {
+			continue
+		}
+
+		keys = append(keys, base64.StdEncoding.EncodeToString(key))
+	}
+
+	return keys
+}
+
+// Infos returns the fingerprints of the keys, the primary key is the
+// first one
+func (k *Keyring) Infos() []KeyInfo {
+	primary := k.keyring.GetPrimaryKey()
+	infos := []KeyInfo{{Fingerprint: Fingerprint(primary), Primary: true}}
+	for _, key := range k.keyring.GetKeys() {
+		if bytes.Equal(key, primary) {
+			continue
+		}
+
+		infos = append(infos, KeyInfo{Fingerprint: Fingerprint(key)})
+	}
+
+	return infos
+}
+
+// Apply applies the operation to the local keyring, applying an
+// operation more than once is harmless.
+func (k *Keyring) Apply(op KeyOp) error {
+	key, err := DecodeKey(op.Key)
+	if err != nil {
+		return err
+	}
+
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	switch op.Op {
+	case KeyInstall:
+		err = k.keyring.AddKey(key)
+	case KeyUse:
+		err = k.keyring.UseKey(key)
+	case KeyRemove:
+		err = k.keyring.RemoveKey(key)
+	default:
+		return errors.Errorf("unknown key operation %q", op.Op)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if k.onChange != nil {
+		k.onChange()
+	}
+
+	return k.save()
+}
+
+// notify sets the callback of the changes of keys
+func (k *Keyring) notify(fn func()) {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	k.onChange = fn
+}
+
+// fingerprints returns the fingerprints of all keys
+func (k *Keyring) fingerprints() []string {
+	infos := k.Infos()
+	fingerprints := make([]string, 0, len(infos))
+	for _, info := range infos {
+		fingerprints = append(fingerprints, info.Fingerprint)
+	}
+
+	return fingerprints
+}
+
+// installed returns true if the key is in the keyring
+func (k *Keyring) installed(key []byte) bool {
+	for _, installed := range k.keyring.GetKeys() {
+		if bytes.Equal(installed, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Save writes the keys to the file, so the keyring survives restarts
+func (k *Keyring) Save() error {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	return k.save()
+}
+
+func (k *Keyring) save() error {
+	if k.path == "" {
+		return nil
+	}
+
+	data := strings.Join(k.Keys(), "\n") + "\n"
+	tmp := k.path + ".tmp"
+	err := os.WriteFile(tmp, []byte(data), 0600)
+	if err != nil {
+		return errors.Wrap(err, "save keys")
+	}
+
+	return os.Rename(tmp, k.path)
+}
+
+// MarshalBinary returns nothing, keys are never synced with the full
+// state, only the operations are broadcast.
+func (k *Keyring) MarshalBinary() ([]byte, error) {
+	return nil, nil
+}
+
+// Merge applies the operation broadcast by other nodes
+func (k *Keyring) Merge(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	var op KeyOp
+	err := json.Unmarshal(b, &op)
+	if err != nil {
+		return err
+	}
+
+	err = k.Apply(op)
+	if err != nil {
+		k.logger.Warn("apply key operation failed",
+			zap.String("op", op.Op),
+			zap.Error(err))
+		return nil
+	}
+
+	k.logger.Info("key operation applied",
+		zap.String("op", op.Op))
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestKeyringRotate(t *testing.T) {
+	var (
+		oldKey = "6qxDT0ytwPZMg+cbE9aWzZlxLqC6FwUS8LcjR0Yz8yk="
+		newKey = "AAECAwQFBgcICQoLDA0ODw=="
+		path   = filepath.Join(t.TempDir(), "keyring")
+	)
+
+	keyring, err := NewKeyring(zap.NewNop(), []string{oldKey}, path)
+	require.NoError(t, err)
+
+	for _, op := range []KeyOp{
+		{Op: KeyInstall, Key: newKey},
+		{Op: KeyUse, Key: newKey},
+	} {
+		data, err := json.Marshal(&op)
+		require.NoError(t, err)
+
+		// merged twice, operations are idempotent
+		require.NoError(t, keyring.Merge(data))
+		require.NoError(t, keyring.Merge(data))
+	}
+	require.Equal(t, []string{newKey, oldKey}, keyring.Keys())
+
+	key, err := DecodeKey(newKey)
+	require.NoError(t, err)
+	infos := keyring.Infos()
+	require.Len(t, infos, 2)
+	require.Equal(t, KeyInfo{Fingerprint: Fingerprint(key), Primary: true}, infos[0])
+	require.Len(t, infos[1].Fingerprint, 16)
+	require.False(t, infos[1].Primary)
+
+	// the primary key cannot be removed
+	require.Error(t, keyring.Apply(KeyOp{Op: KeyRemove, Key: newKey}))
+	require.NoError(t, keyring.Apply(KeyOp{Op: KeyRemove, Key: oldKey}))
+	require.Equal(t, []string{newKey}, keyring.Keys())
+
+	keys, err := LoadKeys(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{newKey}, keys)
+
+	_, err = DecodeKey("AAEC")
+	require.Error(t, err)
+}
+
+func TestKeyStates(t *testing.T) {
+	var (
+		oldKey = "6qxDT0ytwPZMg+cbE9aWzZlxLqC6FwUS8LcjR0Yz8yk="
+		newKey = "AAECAwQFBgcICQoLDA0ODw=="
+	)
+
+	timings, err := PresetLAN.Timings()
+	require.NoError(t, err)
+	newPeer := func() (*Peer, *Keyring) {
+		keyring, err := NewKeyring(zap.NewNop(), []string{oldKey}, "")
+		require.NoError(t, err)
+		p, err := Create(zap.NewNop(), prometheus.NewRegistry(), "127.0.0.1:0", "", "", nil,
+			keyring, nil, nil, false, PresetLAN, timings, CompressionNone)
+		require.NoError(t, err)
+		return p, keyring
+	}
+
+	a, ka := newPeer()
+	defer a.Leave(time.Second)
+	b, _ := newPeer()
+	defer b.Leave(time.Second)
+	results := b.JoinAddrs(context.Background(), []string{a.Self().Address()})
+	require.Empty(t, results[0].Error)
+
+	key, err := DecodeKey(newKey)
+	require.NoError(t, err)
+
+	// installed on a only
+	require.NoError(t, ka.Apply(KeyOp{Op: KeyInstall, Key: newKey}))
+	states, all := a.KeyStates(key)
+	require.False(t, all)
+	require.ElementsMatch(t, []KeyState{
+		{Node: a.Name(), Installed: true},
+		{Node: b.Name(), Installed: false},
+	}, states)
+
+	// b applies the broadcast, and gossips its fingerprints
+	data, err := json.Marshal(&KeyOp{Op: KeyInstall, Key: newKey})
+	require.NoError(t, err)
+	require.NoError(t, b.keyring.Merge(data))
+	require.Eventually(t, func() bool {
+		_, all := a.KeyStates(key)
+		return all
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"context"
+	"net"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ErrPeerAlive is returned when removing a peer which is still alive,
+// alive peers must leave by themselves
+var ErrPeerAlive = errors.New("peer is alive")
+
+// JoinResult is the result of joining the cluster through an address
+type JoinResult struct {
+	Addr string `json:"addr"`
+
+	// Resolved are the addresses the hostname resolved to
+	Resolved []string `json:"resolved,omitempty"`
+
+	// Joined is the number of nodes successfully contacted
+	Joined int    `json:"joined"`
+	Error  string `json:"error,omitempty"`
+}
+
+// JoinAddrs joins the cluster through every address, hostnames are
+// resolved like the known peers, and the result of each address is
+// reported.
+func (p *Peer) JoinAddrs(ctx context.Context, addrs []string) []JoinResult {
+	results := make([]JoinResult, 0, len(addrs))
+	for _, addr := range addrs {
+		result := JoinResult{Addr: addr}
+
+		resolved, err := resolvePeers(ctx, []string{addr}, p.advertiseAddr, &net.Resolver{}, false)
+		if err == nil && len(resolved) == 0 {
+			err = errors.New("no address resolved")
+		}
+
+		if err == nil {
+			result.Resolved = resolved
+			result.Joined, err = p.mlist.Join(resolved)
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+			p.logger.Warn("join failed",
+				zap.String("addr", addr),
+				zap.Error(err))
+		} else {
+			p.logger.Info("joined",
+				zap.String("addr", addr),
+				zap.Int("nodes", result.Joined))
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// RemovePeer evicts the failed peer by name or address from every node,
+// so they stop reconnecting to it. It fails with ErrPeerAlive if the
+// peer is alive.
+func (p *Peer) RemovePeer(id string) error {
+	for _, n := range p.mlist.Members() {
+		if n.Name == id || n.Address() == id {
+			return ErrPeerAlive
+		}
+	}
+
+	if !p.removeFailedPeer(id) {
+		return errors.Errorf("no failed peer %s", id)
+	}
+
+	p.evictions.Broadcast([]byte(id))
+
+	return nil
+}
+
+// removeFailedPeer returns false if the peer is not failed
+func (p *Peer) removeFailedPeer(id string) bool {
+	p.peerLock.Lock()
+	defer p.peerLock.Unlock()
+
+	found := false
+	keep := make([]peer, 0, len(p.failedPeers))
+	for _, pr := range p.failedPeers {
+		if pr.Name != id && pr.Address() != id {
+			keep = append(keep, pr)
+			continue
+		}
+
+		found = true
+		delete(p.peers, pr.Address())
+		p.logger.Info("failed peer removed",
+			zap.String("peer", pr.Name),
+			zap.String("addr", pr.Address()))
+	}
+
+	p.failedPeers = keep
+
+	return found
+}
+
+// evictions is the State of the peers removed by other nodes, only the
+// removals are gossiped.
+type evictions struct {
+	p *Peer
+}
+
+func (e evictions) MarshalBinary() ([]byte, error) {
+	return nil, nil
+}
+
+func (e evictions) Merge(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	id := string(b)
+	for _, n := range e.p.mlist.Members() {
+		if n.Name == id || n.Address() == id {
+			// it came back after the removal
+			return nil
+		}
+	}
+
+	e.p.removeFailedPeer(id)
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestPeer(t *testing.T) *Peer {
+	timings, err := PresetLAN.Timings()
+	require.NoError(t, err)
+
+	p, err := Create(zap.NewNop(), prometheus.NewRegistry(), "127.0.0.1:0", "", "", nil,
+		nil, nil, nil, false, PresetLAN, timings, CompressionNone)
+	require.NoError(t, err)
+
+	return p
+}
+
+func TestJoinAndRemovePeer(t *testing.T) {
+	a := newTestPeer(t)
+	defer a.Leave(time.Second)
+	b := newTestPeer(t)
+	c := newTestPeer(t)
+	defer c.Leave(time.Second)
+
+	results := b.JoinAddrs(context.Background(), []string{a.Self().Address(), "127.0.0.1:1"})
+	require.Len(t, results, 2)
+	require.Equal(t, 1, results[0].Joined)
+	require.Empty(t, results[0].Error)
+	require.NotEmpty(t, results[1].Error)
+
+	results = c.JoinAddrs(context.Background(), []string{a.Self().Address()})
+	require.Empty(t, results[0].Error)
+	require.Eventually(t, func() bool {
+		return len(a.Peers()) == 3 && len(c.Peers()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, ErrPeerAlive, a.RemovePeer(b.Name()))
+
+	name := b.Name()
+	require.NoError(t, b.Leave(time.Second))
+	require.Eventually(t, func() bool {
+		return len(a.Peers()) == 2 && len(c.Peers()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, a.RemovePeer(name))
+	require.Error(t, a.RemovePeer(name))
+
+	// the removal is gossiped
+	require.Eventually(t, func() bool {
+		c.peerLock.RLock()
+		defer c.peerLock.RUnlock()
+		return len(c.failedPeers) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/pkg/errors"
+)
+
+// protocolVersion is the version of the state exchange of this node,
+// nodes of version 1 exchange digests and compress states, nodes older
+// than that advertise none and need the full state as it is.
+const protocolVersion = 1
+
+// Meta is the metadata of a node, it's gossiped along with the alive
+// messages of the node, so its size is limited by memberlist.MetaMaxSize.
+type Meta struct {
+	// Cluster is the name of the cluster the node belongs to, nodes of
+	// other clusters are never merged
+	Cluster string `json:"cluster,omitempty"`
+
+	// Labels describe the topology of the node, e.g. region and zone
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Protocol is the version of the state exchange the node speaks
+	Protocol int `json:"protocol,omitempty"`
+
+	// Keys are the fingerprints of the gossip keys installed, so a key is
+	// used only once every node has it
+	Keys []string `json:"keys,omitempty"`
+}
+
+func (m Meta) encode() ([]byte, error) {
+	data, err := json.Marshal(&m)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > memberlist.MetaMaxSize {
+		return nil, errors.Errorf("node meta is %d bytes, it exceeds the limit %d",
+			len(data), memberlist.MetaMaxSize)
+	}
+
+	return data, nil
+}
+
+// checkCluster returns an error if the node belongs to another cluster
+func checkCluster(name string, n *memberlist.Node) error {
+	meta, err := NodeMeta(n)
+	if err != nil {
+		return errors.Wrap(err, "decode node meta")
+	}
+
+	if meta.Cluster != name {
+		return errors.Errorf("node %s belongs to cluster %q, not %q", n.Name, meta.Cluster, name)
+	}
+
+	return nil
+}
+
+// NodeMeta decodes the metadata of the node, nodes without metadata
+// have an empty one.
+func NodeMeta(n *memberlist.Node) (Meta, error) {
+	var meta Meta
+	if len(n.Meta) == 0 {
+		return meta, nil
+	}
+
+	err := json.Unmarshal(n.Meta, &meta)
+	return meta, err
+}
+
+// digestSupported returns true if all the nodes exchange digests
+func digestSupported(nodes []*memberlist.Node) bool {
+	for _, n := range nodes {
+		meta, err := NodeMeta(n)
+		if err != nil || meta.Protocol < protocolVersion {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,21 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCluster(t *testing.T) {
+	prod, err := Meta{Cluster: "prod"}.encode()
+	require.NoError(t, err)
+
+	require.NoError(t, checkCluster("prod", &memberlist.Node{Name: "a", Meta: prod}))
+	require.Error(t, checkCluster("staging", &memberlist.Node{Name: "a", Meta: prod}))
+
+	// unnamed nodes only join unnamed clusters
+	require.NoError(t, checkCluster("", &memberlist.Node{Name: "b"}))
+	require.Error(t, checkCluster("prod", &memberlist.Node{Name: "b"}))
+	require.Error(t, checkCluster("prod", &memberlist.Node{Name: "c", Meta: []byte("{")}))
+}
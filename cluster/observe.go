@@ -1,6 +1,9 @@
 package cluster
 
 import (
+	"sync"
+	"time"
+
 	"github.com/hashicorp/memberlist"
 )
 
@@ -8,11 +11,147 @@ type Action string
 
 const (
 	PeerJoin   Action = "join"
-	PeerUpdate        = "update"
-	PeerLeave         = "leave"
+	PeerUpdate Action = "update"
+	PeerLeave  Action = "leave"
 )
 
 type PeerObservation struct {
-	Action Action
-	Node   *memberlist.Node
+	Action Action           `json:"action"`
+	Time   time.Time        `json:"time"`
+	Node   *memberlist.Node `json:"node"`
+}
+
+// maxQueuedObservations bounds the observations queued for a consumer
+const maxQueuedObservations = 1024
+
+// Subscription delivers the observations of peers in order, none of them
+// is dropped, observations are queued while the consumer is busy. If the
+// consumer falls too far behind, the subscription is closed instead, so
+// the consumer knows it has missed some and must subscribe again.
+type Subscription struct {
+	ch     chan PeerObservation
+	notify chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	cancel func()
+
+	mtx      sync.Mutex
+	queue    []PeerObservation
+	overflow bool
+}
+
+func newSubscription(cancel func()) *Subscription {
+	s := &Subscription{
+		ch:     make(chan PeerObservation),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+
+	go s.run()
+
+	return s
+}
+
+// C returns the channel of observations, it's closed after Close
+func (s *Subscription) C() <-chan PeerObservation {
+	return s.ch
+}
+
+// Close stops the delivery, the observations queued are discarded
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.cancel()
+		close(s.done)
+	})
+}
+
+func (s *Subscription) publish(o PeerObservation) {
+	s.mtx.Lock()
+	if s.overflow {
+		s.mtx.Unlock()
+		return
+	}
+
+	if len(s.queue) >= maxQueuedObservations {
+		s.overflow = true
+		s.queue = nil
+		s.mtx.Unlock()
+
+		// the caller might hold the lock the cancel takes
+		go s.Close()
+		return
+	}
+
+	s.queue = append(s.queue, o)
+	s.mtx.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Subscription) run() {
+	defer close(s.ch)
+
+	for {
+		s.mtx.Lock()
+		if len(s.queue) == 0 {
+			s.mtx.Unlock()
+
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		o := s.queue[0]
+		s.queue[0] = PeerObservation{}
+		s.queue = s.queue[1:]
+		s.mtx.Unlock()
+
+		select {
+		case s.ch <- o:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Subscribe returns a Subscription of the joins, updates and leaves of
+// peers from now on, it must be closed once it's not needed.
+func (p *Peer) Subscribe() *Subscription {
+	var s *Subscription
+	s = newSubscription(func() {
+		p.subsMtx.Lock()
+		delete(p.subs, s)
+		p.subsMtx.Unlock()
+	})
+
+	p.subsMtx.Lock()
+	p.subs[s] = struct{}{}
+	p.subsMtx.Unlock()
+
+	return s
+}
+
+// observe publishes the observation to all subscriptions, the node is
+// copied since memberlist keeps updating it.
+func (p *Peer) observe(action Action, n *memberlist.Node) {
+	node := *n
+	o := PeerObservation{
+		Action: action,
+		Time:   time.Now(),
+		Node:   &node,
+	}
+
+	p.subsMtx.Lock()
+	defer p.subsMtx.Unlock()
+
+	for s := range p.subs {
+		s.publish(o)
+	}
 }
@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/require"
+)
+
+func nextObservation(t *testing.T, s *Subscription) PeerObservation {
+	select {
+	case o := <-s.C():
+		return o
+	case <-time.After(5 * time.Second):
+		t.Fatal("no observation received")
+		return PeerObservation{}
+	}
+}
+
+func TestSubscriptionNotLossy(t *testing.T) {
+	s := newSubscription(func() {})
+
+	// the consumer is busy
+	for i := 0; i < 1000; i++ {
+		s.publish(PeerObservation{
+			Action: PeerUpdate,
+			Node:   &memberlist.Node{Name: fmt.Sprint(i)},
+		})
+	}
+
+	for i := 0; i < 1000; i++ {
+		require.Equal(t, fmt.Sprint(i), nextObservation(t, s).Node.Name)
+	}
+
+	s.Close()
+	_, ok := <-s.C()
+	require.False(t, ok)
+}
+
+func TestSubscriptionOverflow(t *testing.T) {
+	cancelled := make(chan struct{})
+	s := newSubscription(func() { close(cancelled) })
+
+	// the consumer is stuck
+	for i := 0; i <= maxQueuedObservations; i++ {
+		s.publish(PeerObservation{
+			Action: PeerUpdate,
+			Node:   &memberlist.Node{Name: fmt.Sprint(i)},
+		})
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not closed")
+	}
+
+	// the queued ones are discarded, the channel tells the consumer
+	for range s.C() {
+	}
+	require.Nil(t, s.queue)
+}
+
+func TestSubscribe(t *testing.T) {
+	a := newTestPeer(t)
+	defer a.Leave(time.Second)
+
+	s1 := a.Subscribe()
+	defer s1.Close()
+	s2 := a.Subscribe()
+
+	b := newTestPeer(t)
+	results := b.JoinAddrs(context.Background(), []string{a.Self().Address()})
+	require.Empty(t, results[0].Error)
+
+	o := nextObservation(t, s1)
+	require.Equal(t, PeerJoin, o.Action)
+	require.Equal(t, b.Name(), o.Node.Name)
+	require.Equal(t, PeerJoin, nextObservation(t, s2).Action)
+
+	// closed subscriptions receive nothing
+	s2.Close()
+	require.NotContains(t, a.subs, s2)
+
+	require.NoError(t, b.Leave(time.Second))
+	o = nextObservation(t, s1)
+	require.Equal(t, PeerLeave, o.Action)
+	require.Equal(t, b.Name(), o.Node.Name)
+}
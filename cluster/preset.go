@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/pkg/errors"
+)
+
+// Preset tunes memberlist for the network the cluster runs on
+type Preset string
+
+const (
+	// PresetLAN suits nodes in the same datacenter, it's the default
+	PresetLAN Preset = "lan"
+	// PresetWAN suits nodes across datacenters, failures are detected
+	// slower but false positives are rarer on links of high latency
+	PresetWAN Preset = "wan"
+)
+
+// Timings are the intervals and timeouts of the gossip, the failure
+// detection and the reconnection of failed peers.
+type Timings struct {
+	PushPullInterval  time.Duration
+	GossipInterval    time.Duration
+	TCPTimeout        time.Duration
+	ProbeTimeout      time.Duration
+	ProbeInterval     time.Duration
+	ReconnectInterval time.Duration
+	ReconnectTimeout  time.Duration
+}
+
+// Timings returns the default timings of the preset
+func (p Preset) Timings() (Timings, error) {
+	switch p {
+	case "", PresetLAN:
+		return Timings{
+			PushPullInterval:  DefaultPushPullInterval,
+			GossipInterval:    DefaultGossipInterval,
+			TCPTimeout:        DefaultTcpTimeout,
+			ProbeTimeout:      DefaultProbeTimeout,
+			ProbeInterval:     DefaultProbeInterval,
+			ReconnectInterval: DefaultReconnectInterval,
+			ReconnectTimeout:  DefaultReconnectTimeout,
+		}, nil
+	case PresetWAN:
+		conf := memberlist.DefaultWANConfig()
+		return Timings{
+			PushPullInterval:  conf.PushPullInterval,
+			GossipInterval:    conf.GossipInterval,
+			TCPTimeout:        conf.TCPTimeout,
+			ProbeTimeout:      conf.ProbeTimeout,
+			ProbeInterval:     conf.ProbeInterval,
+			ReconnectInterval: 3 * DefaultReconnectInterval,
+			ReconnectTimeout:  DefaultReconnectTimeout,
+		}, nil
+	default:
+		return Timings{}, errors.Errorf("unknown preset %q", p)
+	}
+}
+
+// config returns the memberlist config the preset is based on
+func (p Preset) config() *memberlist.Config {
+	if p == PresetWAN {
+		return memberlist.DefaultWANConfig()
+	}
+
+	return memberlist.DefaultLANConfig()
+}
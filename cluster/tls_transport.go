@@ -0,0 +1,271 @@
+package cluster
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// TLSConfig holds the paths of the certificates used for mutual TLS, every
+// node presents the same kind of certificate as both server and client,
+// and verifies the certificate of the other side against the CA.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+
+	// ServerName is checked against the certificates of other nodes, the
+	// hostname is not verified if it's empty, since nodes are dialed by IP.
+	ServerName string
+}
+
+// certificates loads the certificates of TLSConfig, and reloads them
+// once any file is modified.
+type certificates struct {
+	conf TLSConfig
+
+	mtx      sync.Mutex
+	modTimes [3]time.Time
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+}
+
+func (c *certificates) load() (*tls.Certificate, *x509.CertPool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var modTimes [3]time.Time
+	for i, path := range []string{c.conf.CertFile, c.conf.KeyFile, c.conf.CAFile} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			if c.cert != nil {
+				// keep the loaded ones, the files might be being replaced
+				return c.cert, c.pool, nil
+			}
+
+			return nil, nil, err
+		}
+
+		modTimes[i] = fi.ModTime()
+	}
+
+	if c.cert != nil && modTimes == c.modTimes {
+		return c.cert, c.pool, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.conf.CertFile, c.conf.KeyFile)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "load certificate")
+	}
+
+	data, err := os.ReadFile(c.conf.CAFile)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "load ca")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, nil, errors.Errorf("no certificate found in %s", c.conf.CAFile)
+	}
+
+	c.cert = &cert
+	c.pool = pool
+	c.modTimes = modTimes
+
+	return c.cert, c.pool, nil
+}
+
+// config returns a tls.Config of the current certificates, the peer
+// certificate is verified against the CA by VerifyConnection, so the
+// hostname check can be skipped.
+func (c *certificates) config(server bool) (*tls.Config, error) {
+	cert, pool, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := x509.ExtKeyUsageServerAuth
+	if server {
+		usage = x509.ExtKeyUsageClientAuth
+	}
+
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		Certificates:       []tls.Certificate{*cert},
+		ClientAuth:         tls.RequireAnyClientCert,
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("no certificate presented")
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				DNSName:       c.conf.ServerName,
+				Roots:         pool,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{usage},
+			})
+
+			return err
+		},
+	}, nil
+}
+
+// TLSTransport is a memberlist.Transport sending packets over plain UDP
+// like memberlist.NetTransport, but wrapping streams, which carry the
+// push/pull of full states and reliable messages, in mutual TLS.
+type TLSTransport struct {
+	*memberlist.NetTransport
+
+	logger   *zap.Logger
+	certs    *certificates
+	timeout  time.Duration
+	streamCh chan net.Conn
+	stopc    chan struct{}
+
+	handshakeFailures *prometheus.CounterVec
+}
+
+// NewTLSTransport creates a TLSTransport listening on the address, timeout
+// bounds the handshake of incoming streams.
+func NewTLSTransport(
+	logger *zap.Logger,
+	reg prometheus.Registerer,
+	conf TLSConfig,
+	bindAddr string,
+	bindPort int,
+	timeout time.Duration,
+) (*TLSTransport, error) {
+	certs := &certificates{conf: conf}
+	_, _, err := certs.load()
+	if err != nil {
+		return nil, err
+	}
+
+	nt, err := memberlist.NewNetTransport(&memberlist.NetTransportConfig{
+		BindAddrs: []string{bindAddr},
+		BindPort:  bindPort,
+		Logger:    log.New(&logWriter{l: logger}, "", 0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TLSTransport{
+		NetTransport: nt,
+		logger:       logger,
+		certs:        certs,
+		timeout:      timeout,
+		streamCh:     make(chan net.Conn),
+		stopc:        make(chan struct{}),
+		handshakeFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gossiping_cluster_tls_handshake_failures_total",
+			Help: "Total number of failed TLS handshakes of streams.",
+		}, []string{"direction"}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(t.handshakeFailures)
+	}
+
+	go t.accept()
+
+	return t, nil
+}
+
+// accept handshakes the streams accepted by the NetTransport
+func (t *TLSTransport) accept() {
+	for {
+		select {
+		case <-t.stopc:
+			return
+		case conn := <-t.NetTransport.StreamCh():
+			go t.handshake(conn)
+		}
+	}
+}
+
+func (t *TLSTransport) handshake(conn net.Conn) {
+	conf, err := t.certs.config(true)
+	if err != nil {
+		t.logger.Warn("load certificates failed",
+			zap.Error(err))
+		conn.Close()
+		return
+	}
+
+	tlsConn := tls.Server(conn, conf)
+	_ = tlsConn.SetDeadline(time.Now().Add(t.timeout))
+	err = tlsConn.Handshake()
+	if err != nil {
+		t.handshakeFailures.WithLabelValues("inbound").Inc()
+		t.logger.Warn("tls handshake failed",
+			zap.String("remote", conn.RemoteAddr().String()),
+			zap.Error(err))
+		conn.Close()
+		return
+	}
+	_ = tlsConn.SetDeadline(time.Time{})
+
+	select {
+	case t.streamCh <- tlsConn:
+	case <-t.stopc:
+		tlsConn.Close()
+	}
+}
+
+// StreamCh returns the streams which passed the handshake
+func (t *TLSTransport) StreamCh() <-chan net.Conn {
+	return t.streamCh
+}
+
+// DialTimeout dials the address and handshakes, timeout bounds both.
+func (t *TLSTransport) DialTimeout(addr string, timeout time.Duration) (net.Conn, error) {
+	conf, err := t.certs.config(false)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, conf)
+	_ = tlsConn.SetDeadline(deadline)
+	err = tlsConn.Handshake()
+	if err != nil {
+		t.handshakeFailures.WithLabelValues("outbound").Inc()
+		conn.Close()
+		return nil, err
+	}
+	_ = tlsConn.SetDeadline(time.Time{})
+
+	return tlsConn, nil
+}
+
+// DialAddressTimeout implements memberlist.NodeAwareTransport
+func (t *TLSTransport) DialAddressTimeout(a memberlist.Address, timeout time.Duration) (net.Conn, error) {
+	return t.DialTimeout(a.Addr, timeout)
+}
+
+// Shutdown stops handshaking and closes the listeners
+func (t *TLSTransport) Shutdown() error {
+	close(t.stopc)
+	return t.NetTransport.Shutdown()
+}
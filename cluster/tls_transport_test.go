@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// writeCerts writes a CA and a node certificate signed by it to dir
+func writeCerts(t *testing.T, dir string) TLSConfig {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	node := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "node"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	nodeDER, err := x509.CreateCertificate(rand.Reader, node, ca, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	conf := TLSConfig{
+		CertFile: filepath.Join(dir, "node.pem"),
+		KeyFile:  filepath.Join(dir, "node-key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	}
+	for path, block := range map[string]*pem.Block{
+		conf.CertFile: {Type: "CERTIFICATE", Bytes: nodeDER},
+		conf.KeyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+		conf.CAFile:   {Type: "CERTIFICATE", Bytes: caDER},
+	} {
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+	}
+
+	return conf
+}
+
+func TestTLSTransport(t *testing.T) {
+	conf := writeCerts(t, t.TempDir())
+
+	a, err := NewTLSTransport(zap.NewNop(), nil, conf, "127.0.0.1", 0, time.Second)
+	require.NoError(t, err)
+	defer a.Shutdown()
+
+	b, err := NewTLSTransport(zap.NewNop(), nil, conf, "127.0.0.1", 0, time.Second)
+	require.NoError(t, err)
+	defer b.Shutdown()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", b.GetAutoBindPort())
+	conn, err := a.DialTimeout(addr, time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+
+	select {
+	case accepted := <-b.StreamCh():
+		buf := make([]byte, 4)
+		_, err = io.ReadFull(accepted, buf)
+		require.NoError(t, err)
+		require.Equal(t, "ping", string(buf))
+		accepted.Close()
+	case <-time.After(time.Second):
+		t.Fatal("no stream accepted")
+	}
+
+	// clients without certificate are rejected
+	plain, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err == nil {
+		_, err = plain.Read(make([]byte, 1))
+		plain.Close()
+	}
+	require.Error(t, err)
+
+	select {
+	case <-b.StreamCh():
+		t.Fatal("stream without certificate accepted")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -1,14 +1,14 @@
 package cluster
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 
+	"github.com/f1shl3gs/gossiping/cluster"
 	"github.com/f1shl3gs/gossiping/cmd/gossiping/internal"
 	"github.com/hashicorp/memberlist"
 	"github.com/olekukonko/tablewriter"
@@ -25,7 +25,10 @@ func New() *cobra.Command {
 	cmd.PersistentFlags().String("host", "http://localhost:9000", "address of gossiping daemon to interact")
 
 	cmd.AddCommand(joinCmd())
+	cmd.AddCommand(leaveCmd())
+	cmd.AddCommand(removeCmd())
 	cmd.AddCommand(listCmd())
+	cmd.AddCommand(keysCmd())
 
 	return cmd
 }
@@ -72,31 +75,38 @@ func listCmd() *cobra.Command {
 
 func joinCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "join",
+		Use:   "join <addr>...",
 		Short: "join to the gossip cluster",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			host, err := cmd.PersistentFlags().GetString("host")
+			cli := internal.ClientFromCmd(cmd)
+			results := make([]cluster.JoinResult, 0)
+			err := cli.Do(context.Background(), http.MethodPost, "/cluster", &args, &results)
 			if err != nil {
 				return err
 			}
 
-			buf := bytes.NewBuffer(nil)
-			err = json.NewEncoder(buf).Encode(&args)
-			if err != nil {
-				return err
-			}
+			failed := 0
+			w := tablewriter.NewWriter(os.Stdout)
+			w.SetAutoFormatHeaders(false)
+			w.SetHeader([]string{"Addr", "Resolved", "Joined", "Error"})
+			for _, result := range results {
+				if result.Error != "" {
+					failed++
+				}
 
-			url := fmt.Sprintf("%s/cluster", host)
-			resp, err := http.Post(url, "application/json", buf)
-			if err != nil {
-				return err
+				w.Append([]string{
+					result.Addr,
+					strings.Join(result.Resolved, ","),
+					strconv.Itoa(result.Joined),
+					result.Error,
+				})
 			}
 
-			defer resp.Body.Close()
+			w.Render()
 
-			if resp.StatusCode != http.StatusNoContent {
-				return errors.Errorf("unexpected status code, %d", resp.StatusCode)
+			if failed != 0 {
+				return errors.Errorf("%d of %d addresses failed", failed, len(results))
 			}
 
 			return nil
@@ -105,3 +115,32 @@ func joinCmd() *cobra.Command {
 
 	return cmd
 }
+
+func leaveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "leave",
+		Short: "make the node leave the cluster gracefully and stop",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cli := internal.ClientFromCmd(cmd)
+			return cli.Do(context.Background(), http.MethodPost, "/cluster/leave", nil, nil)
+		},
+	}
+
+	return cmd
+}
+
+func removeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "force-remove <name|addr>",
+		Aliases: []string{"rm"},
+		Short:   "evict a failed member, so nodes stop reconnecting to it",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cli := internal.ClientFromCmd(cmd)
+			return cli.Do(context.Background(), http.MethodDelete, "/cluster/members/"+url.PathEscape(args[0]), nil, nil)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/f1shl3gs/gossiping/cluster"
+	"github.com/f1shl3gs/gossiping/cmd/gossiping/internal"
+	"github.com/spf13/cobra"
+)
+
+func keysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "manage the keys of gossip encryption",
+		Long: `manage the keys of gossip encryption, keys are rotated online by
+installing the new key, using it, and then removing the old one`,
+	}
+
+	cmd.AddCommand(listKeysCmd())
+	cmd.AddCommand(keyOpCmd(cluster.KeyInstall, "install a key on every node, it's used to decrypt only"))
+	cmd.AddCommand(keyOpCmd(cluster.KeyUse, "make an installed key the primary key of every node"))
+	cmd.AddCommand(keyOpCmd(cluster.KeyRemove, "remove a key from every node, the primary key cannot be removed"))
+
+	return cmd
+}
+
+func listKeysCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "list fingerprints of the keys of the node, the primary key is the first one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cli := internal.ClientFromCmd(cmd)
+			infos := make([]cluster.KeyInfo, 0)
+			err := cli.Get(context.Background(), "/cluster/keys", &infos)
+			if err != nil {
+				return err
+			}
+
+			for _, info := range infos {
+				if info.Primary {
+					fmt.Println(info.Fingerprint, "primary")
+				} else {
+					fmt.Println(info.Fingerprint)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func keyOpCmd(op, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   op + " <key>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := cluster.DecodeKey(args[0])
+			if err != nil {
+				return err
+			}
+
+			cli := internal.ClientFromCmd(cmd)
+			payload := &cluster.KeyOp{Op: op, Key: args[0]}
+			if op != cluster.KeyUse {
+				return cli.Post(context.Background(), "/cluster/keys/"+op, payload)
+			}
+
+			// the key is used only if every node has it, which are listed
+			var states []cluster.KeyState
+			err = cli.Do(context.Background(), http.MethodPost, "/cluster/keys/"+op, payload, &states)
+			if err != nil {
+				return err
+			}
+
+			for _, state := range states {
+				fmt.Println(state.Node, "installed")
+			}
+
+			return nil
+		},
+	}
+}
@@ -46,6 +46,46 @@ func (cli *Client) Get(ctx context.Context, url string, dst interface{}) error {
 	return json.NewDecoder(resp.Body).Decode(dst)
 }
 
+// Do sends the payload encoded in JSON if it's not nil, and decodes the
+// response into dst if it's not nil, any status but 2xx is an error.
+func (cli *Client) Do(ctx context.Context, method, url string, payload, dst interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		buf := bytes.NewBuffer(nil)
+		err := json.NewEncoder(buf).Encode(payload)
+		if err != nil {
+			return err
+		}
+
+		body = buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cli.host+url, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("unexpected status code %d, resp: %s", resp.StatusCode, data)
+	}
+
+	if dst == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
+
 func (cli *Client) Post(ctx context.Context, url string, payload interface{}) error {
 	if payload == nil {
 		return errors.New("payload cannot be empty")
@@ -86,13 +86,15 @@ func list() *cobra.Command {
 			})
 
 			table := tablewriter.NewWriter(os.Stdout)
-			table.SetHeader([]string{"Name", "Status", "Updated", "Targets", "Labels"})
+			table.SetHeader([]string{"Name", "Status", "Updated", "Probe", "Replicas", "Targets", "Labels"})
 
 			for _, ent := range entries {
 				table.Append([]string{
 					ent.Name,
 					targetpb.Status_name[int32(ent.Status)],
 					ent.Updated.Local().Format(time.RFC3339),
+					probeType(ent.Targetgroup.GetProbe()),
+					replicas(ent.Targetgroup.GetReplicas()),
 					strconv.Itoa(len(ent.Targetgroup.Targets)),
 					mapToStr(ent.Targetgroup.Labels),
 				})
@@ -143,6 +145,22 @@ func remove() *cobra.Command {
 	return cmd
 }
 
+func probeType(probe *targetpb.Probe) string {
+	if probe.GetType() == "" {
+		return "icmp"
+	}
+
+	return probe.GetType()
+}
+
+func replicas(n uint32) string {
+	if n == 0 {
+		return "all"
+	}
+
+	return strconv.Itoa(int(n))
+}
+
 func mapToStr(m map[string]string) string {
 	keys := make([]string, len(m))
 	for k := range m {
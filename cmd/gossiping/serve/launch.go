@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -30,6 +33,12 @@ import (
 const (
 	defaultHttpAddress = ":9000"
 	defaultClusterAddr = "0.0.0.0:9094"
+
+	// tombstoneGCInterval is the interval between two purges of tombstones
+	tombstoneGCInterval = time.Minute
+
+	// eventWriteTimeout bounds every write of the cluster events
+	eventWriteTimeout = 10 * time.Second
 )
 
 func launch(conf config.Config) error {
@@ -40,55 +49,153 @@ func launch(conf config.Config) error {
 
 	defer logger.Sync()
 
+	if conf.Tasks.UnprivilegedICMP {
+		err = tasks.UseUnprivilegedICMP()
+		if err != nil {
+			return err
+		}
+
+		logger.Info("unprivileged icmp is enabled")
+	}
+
+	keyring, err := newKeyring(logger, conf.Cluster)
+	if err != nil {
+		return errors.Wrap(err, "create keyring failed")
+	}
+
+	var tlsConf *cluster.TLSConfig
+	if conf.Cluster.TLS != nil {
+		logger.Info("cluster tls is enabled")
+		tlsConf = &cluster.TLSConfig{
+			CertFile:   conf.Cluster.TLS.CertFile,
+			KeyFile:    conf.Cluster.TLS.KeyFile,
+			CAFile:     conf.Cluster.TLS.CAFile,
+			ServerName: conf.Cluster.TLS.ServerName,
+		}
+	}
+
+	bindAddr := conf.Cluster.BindAddr
+	if bindAddr == "" {
+		bindAddr = defaultClusterAddr
+	}
+
+	listenAddr := conf.Web.ListenAddress
+	if listenAddr == "" {
+		listenAddr = defaultHttpAddress
+	}
+
+	preset := cluster.Preset(conf.Cluster.Preset)
+	timings, err := conf.Cluster.Timings()
+	if err != nil {
+		return err
+	}
+
 	peer, err := cluster.Create(
 		logger,
 		prometheus.DefaultRegisterer,
-		defaultClusterAddr,
+		bindAddr,
 		conf.Cluster.AdvertiseAddr,
+		conf.Cluster.Name,
+		conf.Cluster.Labels,
+		keyring,
+		tlsConf,
 		conf.Cluster.Peers,
 		true,
-		cluster.DefaultPushPullInterval,
-		cluster.DefaultGossipInterval,
-		cluster.DefaultTcpTimeout,
-		cluster.DefaultProbeTimeout,
-		cluster.DefaultProbeInterval)
+		preset,
+		timings,
+		cluster.Compression(conf.Cluster.Compression))
 	if err != nil {
 		return errors.Wrap(err, "create cluster failed")
 	}
 
-	store := tasks.NewStore()
+	var keyCh *cluster.Channel
+	if keyring != nil {
+		logger.Info("gossip encryption is enabled")
+		keyCh = peer.AddState("keyring", keyring, prometheus.DefaultRegisterer)
+	}
+
+	store := tasks.NewStore(peer.Name())
 	ch := peer.AddState("tg", store, prometheus.DefaultRegisterer)
 	broadcast := func(me *targetpb.MeshEntry) error {
+		store.Stamp(me)
+
 		buf := bytes.NewBuffer(nil)
 		_, err := pbutil.WriteDelimited(buf, me)
 		if err != nil {
 			return err
 		}
 
+		// broadcasts are never delivered to the local node
+		err = store.Merge(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
 		ch.Broadcast(buf.Bytes())
 
-		return err
+		return nil
 	}
 
 	// collector
-	collector := tasks.New(logger, conf.Global.ExternalLabels)
+	collector := tasks.New(logger, conf.Global.ExternalLabels, &targetpb.Histogram{
+		Buckets:            conf.Tasks.Histogram.Buckets,
+		NativeBucketFactor: conf.Tasks.Histogram.NativeBucketFactor,
+	})
 	prometheus.MustRegister(collector)
 
+	var mesh *tasks.Mesh
 	if !conf.Tasks.DryRun {
 		store.AddCallback("collector", collector.Coordinate)
+
+		// released once gossip settles
+		collector.Hold()
+
+		if conf.Tasks.Mesh.Enabled {
+			logger.Info("mesh is enabled")
+			mesh = tasks.NewMesh(collector, &targetpb.Probe{
+				Interval: conf.Tasks.Mesh.Interval,
+			})
+		}
 	} else {
 		logger.Info("dry run is enabled for tasks")
 	}
 
+	// verdicts
+	quorum := conf.Tasks.Verdict.Quorum
+	if quorum == 0 {
+		quorum = tasks.DefaultQuorum
+	}
+	reportInterval := conf.Tasks.Verdict.Interval
+	if reportInterval == 0 {
+		reportInterval = tasks.DefaultReportInterval
+	}
+	verdicts := tasks.NewVerdicts(logger, collector, peer.Name(), quorum, reportInterval)
+	prometheus.MustRegister(verdicts)
+	verdictCh := peer.AddState("health", verdicts, prometheus.DefaultRegisterer)
+
+	// members must be known before any job is coordinated, the jobs
+	// might be restricted to some of them
+	updateMembers(logger, peer, collector, mesh)
+
+	retention := conf.Tasks.TombstoneRetention
+	if retention == 0 {
+		retention = tasks.DefaultTombstoneRetention
+	}
+
 	// states
+	var gen *state.Generator
 	if conf.Tasks.States != "" {
 		logger.Info("task states is enabled",
 			zap.String("dir", conf.Tasks.States))
 
-		gen := state.New(conf.Tasks.States, logger)
+		gen = state.New(conf.Tasks.States, retention, logger)
 		store.AddCallback("state", gen.OnUpdate)
 	}
 
+	// stop is called by signals or by leaving the cluster
+	ctx, stop := context.WithCancel(signals.WithStandardSignals(context.Background()))
+	defer stop()
+
 	router := httprouter.New()
 	router.Handler(http.MethodGet, "/metrics", promhttp.Handler())
 	router.Handler(http.MethodGet, "/debug/pprof/*dummy", http.DefaultServeMux)
@@ -102,8 +209,254 @@ func launch(conf config.Config) error {
 		}
 	})
 
+	// stream the changes of members as server-sent events, starting with
+	// a join of every alive member
+	router.HandlerFunc(http.MethodGet, "/cluster/events", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("streaming is not supported"))
+			return
+		}
+
+		sub := peer.Subscribe()
+		defer sub.Close()
+
+		// if the client stops reading, the writes fail rather than block
+		// forever, and the subscription is closed
+		rc := http.NewResponseController(w)
+		deadline := func() {
+			_ = rc.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		deadline()
+		w.WriteHeader(http.StatusOK)
+
+		for _, n := range peer.Peers() {
+			err := writeEvent(w, cluster.PeerObservation{
+				Action: cluster.PeerJoin,
+				Time:   time.Now(),
+				Node:   n,
+			})
+			if err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		// comments keep proxies from closing idle streams
+		keepalive := time.NewTicker(30 * time.Second)
+		defer keepalive.Stop()
+
+		for {
+			var err error
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.Context().Done():
+				return
+			case <-keepalive.C:
+				deadline()
+				_, err = w.Write([]byte(": keepalive\n\n"))
+			case o, ok := <-sub.C():
+				if !ok {
+					// the client fell behind, it resyncs on reconnect
+					logger.Debug("cluster events overflowed",
+						zap.String("remote", r.RemoteAddr))
+					return
+				}
+
+				deadline()
+				err = writeEvent(w, o)
+			}
+
+			if err != nil {
+				logger.Debug("write cluster event failed",
+					zap.String("remote", r.RemoteAddr),
+					zap.Error(err))
+				return
+			}
+
+			flusher.Flush()
+		}
+	})
+
+	// join the cluster through the addresses
+	router.HandlerFunc(http.MethodPost, "/cluster", func(w http.ResponseWriter, r *http.Request) {
+		var addrs []string
+		err := json.NewDecoder(r.Body).Decode(&addrs)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if len(addrs) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("no address to join"))
+			return
+		}
+
+		results := peer.JoinAddrs(r.Context(), addrs)
+		err = json.NewEncoder(w).Encode(&results)
+		if err != nil {
+			logger.Warn("encode join results failed",
+				zap.String("remote", r.RemoteAddr),
+				zap.Error(err))
+		}
+	})
+
+	// leave the cluster gracefully and stop
+	router.HandlerFunc(http.MethodPost, "/cluster/leave", func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("leave requested",
+			zap.String("remote", r.RemoteAddr))
+
+		w.WriteHeader(http.StatusAccepted)
+		stop()
+	})
+
+	// evict a failed member from every node
+	router.HandlerFunc(http.MethodDelete, "/cluster/members/:name", func(w http.ResponseWriter, r *http.Request) {
+		params := httprouter.ParamsFromContext(r.Context())
+		err := peer.RemovePeer(params.ByName("name"))
+		if err != nil {
+			if err == cluster.ErrPeerAlive {
+				w.WriteHeader(http.StatusConflict)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// fingerprints of the keys of gossip encryption, the keys themselves
+	// never leave the node
+	router.HandlerFunc(http.MethodGet, "/cluster/keys", func(w http.ResponseWriter, r *http.Request) {
+		if keyring == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("gossip encryption is not enabled"))
+			return
+		}
+
+		err := json.NewEncoder(w).Encode(keyring.Infos())
+		if err != nil {
+			logger.Warn("encode keys failed",
+				zap.String("remote", r.RemoteAddr),
+				zap.Error(err))
+		}
+	})
+
+	// install, use or remove a key on every node, only local clients are
+	// allowed, anyone else could make their key the primary one
+	router.HandlerFunc(http.MethodPost, "/cluster/keys/:op", func(w http.ResponseWriter, r *http.Request) {
+		if keyring == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("gossip encryption is not enabled"))
+			return
+		}
+
+		if !isLoopback(r.RemoteAddr) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("key operations are allowed from localhost only"))
+			return
+		}
+
+		params := httprouter.ParamsFromContext(r.Context())
+		op := cluster.KeyOp{Op: params.ByName("op")}
+		err := json.NewDecoder(r.Body).Decode(&op)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		// the op in the path wins
+		op.Op = params.ByName("op")
+
+		// nodes lacking the primary key can't decrypt gossip anymore, so
+		// a key is used only once every member has it
+		var states []cluster.KeyState
+		if op.Op == cluster.KeyUse {
+			key, err := cluster.DecodeKey(op.Key)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			var all bool
+			states, all = peer.KeyStates(key)
+			if !all {
+				w.WriteHeader(http.StatusConflict)
+				err = json.NewEncoder(w).Encode(&states)
+				if err != nil {
+					logger.Warn("encode key states failed",
+						zap.String("remote", r.RemoteAddr),
+						zap.Error(err))
+				}
+				return
+			}
+		}
+
+		err = keyring.Apply(op)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		data, err := json.Marshal(&op)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		keyCh.Broadcast(data)
+		logger.Info("key operation broadcast",
+			zap.String("op", op.Op))
+
+		if states != nil {
+			err = json.NewEncoder(w).Encode(&states)
+			if err != nil {
+				logger.Warn("encode key states failed",
+					zap.String("remote", r.RemoteAddr),
+					zap.Error(err))
+			}
+		}
+	})
+
+	// liveness, the node is unhealthy if it's likely partitioned
+	router.HandlerFunc(http.MethodGet, "/-/healthy", func(w http.ResponseWriter, r *http.Request) {
+		if !peer.Healthy() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		writeStatus(logger, w, r, peer)
+	})
+
+	// readiness, the node is ready once gossip settles
+	router.HandlerFunc(http.MethodGet, "/-/ready", func(w http.ResponseWriter, r *http.Request) {
+		if !peer.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		writeStatus(logger, w, r, peer)
+	})
+
 	// list jobs
 	router.HandlerFunc(http.MethodGet, "/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if !peer.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("jobs are incomplete until gossip settles"))
+			return
+		}
+
 		err := store.Snapshot(w)
 		if err != nil {
 			logger.Warn("write jobs to client failed",
@@ -112,10 +465,25 @@ func launch(conf config.Config) error {
 		}
 	})
 
+	// verdicts of targets
+	router.HandlerFunc(http.MethodGet, "/verdicts", func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewEncoder(w).Encode(verdicts.Verdicts())
+		if err != nil {
+			logger.Warn("encode verdicts failed",
+				zap.String("remote", r.RemoteAddr),
+				zap.Error(err))
+		}
+	})
+
 	// add jobs
 	router.HandlerFunc(http.MethodPost, "/jobs/:name", func(w http.ResponseWriter, r *http.Request) {
 		params := httprouter.ParamsFromContext(r.Context())
 		name := params.ByName("name")
+		if tasks.ReservedJob(name) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("job names prefixed with __ are reserved"))
+			return
+		}
 
 		var tg targetgroup.Group
 		err = json.NewDecoder(r.Body).Decode(&tg)
@@ -125,11 +493,23 @@ func launch(conf config.Config) error {
 			return
 		}
 
+		group, err := targetpb.FromProm(&tg)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if _, ok := tasks.Lookup(group.Probe.GetType()); !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("unknown probe type " + group.Probe.GetType()))
+			return
+		}
+
 		err = broadcast(&targetpb.MeshEntry{
 			Name:        name,
 			Status:      targetpb.Status_Active,
-			Updated:     time.Now(),
-			Targetgroup: targetpb.FromProm(&tg),
+			Targetgroup: group,
 		})
 		if err != nil {
 			logger.Warn("add job failed",
@@ -141,11 +521,15 @@ func launch(conf config.Config) error {
 	router.HandlerFunc(http.MethodDelete, "/jobs/:name", func(w http.ResponseWriter, r *http.Request) {
 		params := httprouter.ParamsFromContext(r.Context())
 		name := params.ByName("name")
+		if tasks.ReservedJob(name) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("job names prefixed with __ are reserved"))
+			return
+		}
 
 		err = broadcast(&targetpb.MeshEntry{
 			Name:        name,
 			Status:      targetpb.Status_Inactive,
-			Updated:     time.Now(),
 			Targetgroup: nil,
 		})
 		if err != nil {
@@ -155,12 +539,14 @@ func launch(conf config.Config) error {
 	})
 
 	// join the cluster
-	err = peer.Join(cluster.DefaultReconnectInterval, cluster.DefaultReconnectTimeout)
+	err = peer.Join(timings.ReconnectInterval, timings.ReconnectTimeout)
 	if err != nil {
 		logger.Warn("errors occurred when join cluster",
 			zap.Error(err))
 	}
 
+	go peer.Settle(ctx, 10*timings.GossipInterval)
+
 	defer func() {
 		err = peer.Leave(3 * time.Second)
 		if err != nil {
@@ -169,13 +555,12 @@ func launch(conf config.Config) error {
 		}
 	}()
 
-	ctx := signals.WithStandardSignals(context.Background())
 	group, ctx := errgroup.WithContext(ctx)
 
 	// http server
 	group.Go(func() error {
 		server := http.Server{
-			Addr:    defaultHttpAddress,
+			Addr:    listenAddr,
 			Handler: router,
 		}
 
@@ -209,12 +594,23 @@ func launch(conf config.Config) error {
 	}
 
 	group.Go(func() error {
-		if err := updateGossipingJob(peer, broadcast); err != nil {
-			logger.Warn("initial gossiping job failed",
-				zap.Error(err))
-		}
+		verdicts.Run(ctx, verdictCh.Broadcast)
+		return nil
+	})
 
-		ticker := time.NewTicker(30 * time.Minute)
+	if gen != nil {
+		group.Go(func() error {
+			gen.Run(ctx)
+			return nil
+		})
+	}
+
+	group.Go(func() error {
+		// peers are incomplete until gossip settles, tombstones would be
+		// purged before they are seen
+		peer.WaitReady()
+
+		ticker := time.NewTicker(tombstoneGCInterval)
 		defer ticker.Stop()
 
 		for {
@@ -222,10 +618,40 @@ func launch(conf config.Config) error {
 			case <-ctx.Done():
 				return nil
 			case <-ticker.C:
-			case <-peer.Changed():
 			}
 
-			err = updateGossipingJob(peer, broadcast)
+			var peers []string
+			for _, n := range peer.Peers() {
+				if n.Name != peer.Name() {
+					peers = append(peers, n.Name)
+				}
+			}
+
+			for _, me := range store.GC(peers, retention) {
+				logger.Debug("purge tombstone",
+					zap.String("job", me.Name))
+
+				if gen != nil {
+					gen.Purge(me)
+				}
+			}
+		}
+	})
+
+	group.Go(func() error {
+		// jobs, members and the sd file are incomplete until gossip settles,
+		// and the jobs must be assigned among the settled members
+		peer.WaitReady()
+		updateMembers(logger, peer, collector, mesh)
+		collector.Release()
+
+		ticker := time.NewTicker(30 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			updateMembers(logger, peer, collector, mesh)
+
+			err := updateGossipingJob(peer, broadcast)
 			if err != nil {
 				logger.Warn("update gossiping job failed",
 					zap.Error(err))
@@ -234,10 +660,7 @@ func launch(conf config.Config) error {
 			}
 
 			if conf.Prometheus.Output != "" {
-				logger.Info("prometheus sd is configured",
-					zap.String("filepath", conf.Prometheus.Output))
-
-				err := generatePromConfig(peer, conf.Prometheus.Output)
+				err := generatePromConfig(peer, listenAddr, conf.Prometheus.Output)
 				if err != nil {
 					logger.Warn("generate prometheus sd file failed",
 						zap.Error(err))
@@ -245,12 +668,96 @@ func launch(conf config.Config) error {
 					logger.Info("regenerate prometheus sd file success")
 				}
 			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			case <-peer.Changed():
+			}
 		}
 	})
 
 	return group.Wait()
 }
 
+// newKeyring creates the keyring of gossip encryption, it's nil if no key
+// is configured. The key file is authoritative once it has keys, since
+// keys rotated online are saved to it, the keys in the config only seed
+// a missing or empty one, otherwise removed keys would come back.
+func newKeyring(logger *zap.Logger, conf config.Cluster) (*cluster.Keyring, error) {
+	if conf.EncryptionKeyFile != "" {
+		keys, err := cluster.LoadKeys(conf.EncryptionKeyFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if len(keys) != 0 {
+			return cluster.NewKeyring(logger, keys, conf.EncryptionKeyFile)
+		}
+	}
+
+	if len(conf.EncryptionKeys) == 0 {
+		if conf.EncryptionKeyFile != "" {
+			return nil, errors.Errorf("no key found in %s", conf.EncryptionKeyFile)
+		}
+
+		return nil, nil
+	}
+
+	keyring, err := cluster.NewKeyring(logger, conf.EncryptionKeys, conf.EncryptionKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = keyring.Save()
+	if err != nil {
+		return nil, err
+	}
+
+	return keyring, nil
+}
+
+// isLoopback returns true if the remote address is a loopback one
+func isLoopback(remote string) bool {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// writeEvent writes the observation as a server-sent event
+func writeEvent(w io.Writer, o cluster.PeerObservation) error {
+	data, err := json.Marshal(&o)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", o.Action, data)
+	return err
+}
+
+// writeStatus writes the status and the health score of the peer
+func writeStatus(logger *zap.Logger, w http.ResponseWriter, r *http.Request, peer *cluster.Peer) {
+	status := struct {
+		Status      string `json:"status"`
+		HealthScore int    `json:"health_score"`
+	}{
+		Status:      peer.Status(),
+		HealthScore: peer.HealthScore(),
+	}
+
+	err := json.NewEncoder(w).Encode(&status)
+	if err != nil {
+		logger.Warn("encode status failed",
+			zap.String("remote", r.RemoteAddr),
+			zap.Error(err))
+	}
+}
+
 func updateGossipingJob(peer *cluster.Peer, broadcast func(me *targetpb.MeshEntry) error) error {
 	if peer.Position() != 0 {
 		return nil
@@ -259,7 +766,6 @@ func updateGossipingJob(peer *cluster.Peer, broadcast func(me *targetpb.MeshEntr
 	me := &targetpb.MeshEntry{
 		Name:        "__gossiping",
 		Status:      targetpb.Status_Active,
-		Updated:     time.Now(),
 		Targetgroup: &targetpb.Targetgroup{},
 	}
 
@@ -270,7 +776,44 @@ func updateGossipingJob(peer *cluster.Peer, broadcast func(me *targetpb.MeshEntr
 	return broadcast(me)
 }
 
-func generatePromConfig(peer *cluster.Peer, output string) error {
+// updateMembers makes the collector and the mesh follow the alive
+// members of the cluster, mesh is nil if it's not enabled.
+func updateMembers(logger *zap.Logger, peer *cluster.Peer, collector *tasks.Collector, mesh *tasks.Mesh) {
+	var self tasks.Member
+	nodes := peer.Peers()
+	members := make([]tasks.Member, 0, len(nodes))
+	for _, n := range nodes {
+		meta, err := cluster.NodeMeta(n)
+		if err != nil {
+			logger.Warn("decode node meta failed",
+				zap.String("peer", n.Name),
+				zap.Error(err))
+		}
+
+		member := tasks.Member{
+			Name:   n.Name,
+			Addr:   n.Addr.String(),
+			Labels: meta.Labels,
+		}
+		if n.Name == peer.Name() {
+			self = member
+		}
+
+		members = append(members, member)
+	}
+
+	collector.SetMembers(self, members)
+	if mesh != nil {
+		mesh.Update(self.Name, members)
+	}
+}
+
+func generatePromConfig(peer *cluster.Peer, listenAddr, output string) error {
+	_, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return err
+	}
+
 	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
@@ -292,7 +835,7 @@ func generatePromConfig(peer *cluster.Peer, output string) error {
 	}
 
 	for _, p := range peer.Peers() {
-		promConf.Targets = append(promConf.Targets, p.Addr.String()+defaultHttpAddress)
+		promConf.Targets = append(promConf.Targets, net.JoinHostPort(p.Addr.String(), port))
 	}
 
 	return yaml.NewEncoder(f).Encode(&promConf)
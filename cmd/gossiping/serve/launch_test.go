@@ -0,0 +1,49 @@
+package serve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/f1shl3gs/gossiping/cluster"
+	"github.com/f1shl3gs/gossiping/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewKeyring(t *testing.T) {
+	var (
+		oldKey = "6qxDT0ytwPZMg+cbE9aWzZlxLqC6FwUS8LcjR0Yz8yk="
+		newKey = "AAECAwQFBgcICQoLDA0ODw=="
+		path   = filepath.Join(t.TempDir(), "keyring")
+	)
+
+	keyring, err := newKeyring(zap.NewNop(), config.Cluster{})
+	require.NoError(t, err)
+	require.Nil(t, keyring)
+
+	// the config seeds the missing key file
+	conf := config.Cluster{EncryptionKeys: []string{oldKey}, EncryptionKeyFile: path}
+	keyring, err = newKeyring(zap.NewNop(), conf)
+	require.NoError(t, err)
+	keys, err := cluster.LoadKeys(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{oldKey}, keys)
+
+	// keys removed online don't come back from the config
+	require.NoError(t, keyring.Apply(cluster.KeyOp{Op: cluster.KeyInstall, Key: newKey}))
+	require.NoError(t, keyring.Apply(cluster.KeyOp{Op: cluster.KeyUse, Key: newKey}))
+	require.NoError(t, keyring.Apply(cluster.KeyOp{Op: cluster.KeyRemove, Key: oldKey}))
+	keyring, err = newKeyring(zap.NewNop(), conf)
+	require.NoError(t, err)
+	require.Equal(t, []string{newKey}, keyring.Keys())
+
+	// and an empty key file is seeded again
+	require.NoError(t, os.WriteFile(path, []byte("# rotated\n"), 0600))
+	keyring, err = newKeyring(zap.NewNop(), conf)
+	require.NoError(t, err)
+	require.Equal(t, []string{oldKey}, keyring.Keys())
+
+	_, err = newKeyring(zap.NewNop(), config.Cluster{EncryptionKeyFile: filepath.Join(t.TempDir(), "missing")})
+	require.Error(t, err)
+}
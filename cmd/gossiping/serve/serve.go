@@ -28,6 +28,11 @@ func Serve() *cobra.Command {
 				return err
 			}
 
+			err = conf.Valid()
+			if err != nil {
+				return err
+			}
+
 			return launch(conf)
 		},
 	}
@@ -1,5 +1,17 @@
 package config
 
+import (
+	"encoding/base64"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/cluster"
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
 type Global struct {
 	ExternalLabels map[string]string `json:"external_labels" yaml:"external_labels"`
 }
@@ -9,22 +21,274 @@ type Prometheus struct {
 }
 
 type Cluster struct {
-	Peers         []string `json:"peers" yaml:"peers"`
-	AdvertiseAddr string   `json:"advertise_addr" yaml:"advertise_addr"`
+	// Name of the cluster, nodes of clusters named differently reject
+	// each other, so they never merge by accident.
+	Name string `json:"name" yaml:"name"`
+
+	Peers []string `json:"peers" yaml:"peers"`
+
+	// BindAddr is the address gossip listens on, "0.0.0.0:9094" by default
+	BindAddr      string `json:"bind_addr" yaml:"bind_addr"`
+	AdvertiseAddr string `json:"advertise_addr" yaml:"advertise_addr"`
+
+	// Preset is "lan" or "wan", it provides the timings below which
+	// are not set, "lan" by default.
+	Preset            string        `json:"preset" yaml:"preset"`
+	PushPullInterval  time.Duration `json:"push_pull_interval" yaml:"push_pull_interval"`
+	GossipInterval    time.Duration `json:"gossip_interval" yaml:"gossip_interval"`
+	TCPTimeout        time.Duration `json:"tcp_timeout" yaml:"tcp_timeout"`
+	ProbeInterval     time.Duration `json:"probe_interval" yaml:"probe_interval"`
+	ProbeTimeout      time.Duration `json:"probe_timeout" yaml:"probe_timeout"`
+	ReconnectInterval time.Duration `json:"reconnect_interval" yaml:"reconnect_interval"`
+	ReconnectTimeout  time.Duration `json:"reconnect_timeout" yaml:"reconnect_timeout"`
+
+	// Compression of the states exchanged between nodes, "none",
+	// "snappy" or "zstd", "none" by default.
+	Compression string `json:"compression" yaml:"compression"`
+
+	// Labels describe the topology of the node, e.g. region and zone,
+	// they are gossiped to other nodes, so jobs can select nodes by them.
+	Labels map[string]string `json:"labels" yaml:"labels"`
+
+	// EncryptionKeys are base64 encoded AES keys of 16, 24 or 32 bytes,
+	// the first one encrypts gossip, and all of them decrypt it.
+	EncryptionKeys []string `json:"encryption_keys" yaml:"encryption_keys"`
+
+	// EncryptionKeyFile holds one base64 encoded key per line, the first
+	// one is the primary key, keys installed or removed online are saved
+	// to it, so they survive restarts.
+	EncryptionKeyFile string `json:"encryption_key_file" yaml:"encryption_key_file"`
+
+	// TLS wraps the streams between nodes in mutual TLS, gossip packets
+	// are still sent over UDP, encrypt them with EncryptionKeys.
+	TLS *TLS `json:"tls" yaml:"tls"`
+}
+
+// TLS holds the paths of the certificates, they are reloaded once modified
+type TLS struct {
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+	CAFile   string `json:"ca_file" yaml:"ca_file"`
+
+	// ServerName is checked against the certificates of other nodes, the
+	// hostname is not verified if it's empty.
+	ServerName string `json:"server_name" yaml:"server_name"`
+}
+
+// Histogram is the layout of the latency histograms
+type Histogram struct {
+	// Buckets are the upper bounds of the buckets in seconds
+	Buckets []float64 `json:"buckets" yaml:"buckets"`
+
+	// NativeBucketFactor enables Prometheus native histograms if it's
+	// greater than 1, e.g. 1.1 means each bucket is 10% wider than the
+	// previous one.
+	NativeBucketFactor float64 `json:"native_bucket_factor" yaml:"native_bucket_factor"`
+}
+
+// Mesh makes every node probe every other member of the cluster
+type Mesh struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Interval between two pings, zero means the default
+	Interval time.Duration `json:"interval" yaml:"interval"`
+}
+
+// Verdict configures how nodes agree on the health of targets
+type Verdict struct {
+	// Quorum is the ratio of the probers must see a target down, so the
+	// target is down, zero means 0.5
+	Quorum float64 `json:"quorum" yaml:"quorum"`
+
+	// Interval between two health reports, zero means 15s
+	Interval time.Duration `json:"interval" yaml:"interval"`
 }
 
 type Tasks struct {
 	DryRun bool   `json:"dry_run" yaml:"dry_run"`
 	States string `json:"states" yaml:"states"`
+
+	// UnprivilegedICMP sends pings through ICMP datagram sockets instead
+	// of raw sockets, so the daemon doesn't have to run as root.
+	UnprivilegedICMP bool `json:"unprivileged_icmp" yaml:"unprivileged_icmp"`
+
+	// Histogram is used by jobs which do not set their own
+	Histogram Histogram `json:"histogram" yaml:"histogram"`
+
+	Mesh Mesh `json:"mesh" yaml:"mesh"`
+
+	Verdict Verdict `json:"verdict" yaml:"verdict"`
+
+	// TombstoneRetention is how long deleted jobs are kept at most, they
+	// are purged earlier once all alive nodes have seen the deletion,
+	// zero means 24h
+	TombstoneRetention time.Duration `json:"tombstone_retention" yaml:"tombstone_retention"`
+}
+
+// Web configures the HTTP server serving the API and metrics
+type Web struct {
+	// ListenAddress is ":9000" by default
+	ListenAddress string `json:"listen_address" yaml:"listen_address"`
 }
 
 type Config struct {
 	Global     Global     `json:"global" yaml:"global"`
 	Prometheus Prometheus `json:"prometheus" yaml:"prometheus"`
 	Cluster    Cluster    `json:"cluster" yaml:"cluster"`
+	Web        Web        `json:"web" yaml:"web"`
 	Tasks      Tasks      `json:"tasks" yaml:"tasks"`
 }
 
+// validAddr checks the address is host:port, and the host can be empty
+func validAddr(name, addr string) error {
+	if addr == "" {
+		return nil
+	}
+
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s", name)
+	}
+
+	_, err = strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return errors.Errorf("invalid port of %s %q", name, addr)
+	}
+
+	return nil
+}
+
+func (cluster *Cluster) valid() error {
+	for name, addr := range map[string]string{
+		"cluster bind_addr":      cluster.BindAddr,
+		"cluster advertise_addr": cluster.AdvertiseAddr,
+	} {
+		if err := validAddr(name, addr); err != nil {
+			return err
+		}
+	}
+
+	switch cluster.Preset {
+	case "", "lan", "wan":
+	default:
+		return errors.Errorf("unknown cluster preset %q, it must be lan or wan", cluster.Preset)
+	}
+
+	switch cluster.Compression {
+	case "", "none", "snappy", "zstd":
+	default:
+		return errors.Errorf("unknown cluster compression %q, it must be none, snappy or zstd", cluster.Compression)
+	}
+
+	for name, d := range map[string]time.Duration{
+		"push_pull_interval": cluster.PushPullInterval,
+		"gossip_interval":    cluster.GossipInterval,
+		"tcp_timeout":        cluster.TCPTimeout,
+		"probe_interval":     cluster.ProbeInterval,
+		"probe_timeout":      cluster.ProbeTimeout,
+		"reconnect_interval": cluster.ReconnectInterval,
+		"reconnect_timeout":  cluster.ReconnectTimeout,
+	} {
+		if d < 0 {
+			return errors.Errorf("cluster %s cannot be negative", name)
+		}
+	}
+
+	_, err := cluster.Timings()
+	return err
+}
+
+// Timings returns the timings of the preset, overridden by the ones set,
+// the probe timeout is checked against the interval once they are merged,
+// since either of them might come from the preset.
+func (c *Cluster) Timings() (cluster.Timings, error) {
+	timings, err := cluster.Preset(c.Preset).Timings()
+	if err != nil {
+		return timings, err
+	}
+
+	for _, d := range []struct {
+		dst *time.Duration
+		src time.Duration
+	}{
+		{&timings.PushPullInterval, c.PushPullInterval},
+		{&timings.GossipInterval, c.GossipInterval},
+		{&timings.TCPTimeout, c.TCPTimeout},
+		{&timings.ProbeInterval, c.ProbeInterval},
+		{&timings.ProbeTimeout, c.ProbeTimeout},
+		{&timings.ReconnectInterval, c.ReconnectInterval},
+		{&timings.ReconnectTimeout, c.ReconnectTimeout},
+	} {
+		if d.src != 0 {
+			*d.dst = d.src
+		}
+	}
+
+	if timings.ProbeTimeout > timings.ProbeInterval {
+		return timings, errors.Errorf("cluster probe_timeout %s cannot be greater than probe_interval %s",
+			timings.ProbeTimeout, timings.ProbeInterval)
+	}
+
+	return timings, nil
+}
+
 func (config *Config) Valid() error {
+	err := config.Cluster.valid()
+	if err != nil {
+		return err
+	}
+
+	err = validAddr("web listen_address", config.Web.ListenAddress)
+	if err != nil {
+		return err
+	}
+
+	for name := range config.Cluster.Labels {
+		if !model.LabelName(name).IsValid() {
+			return errors.Errorf("invalid node label name %q", name)
+		}
+	}
+
+	for _, key := range config.Cluster.EncryptionKeys {
+		data, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return errors.Wrap(err, "invalid encryption key")
+		}
+
+		if n := len(data); n != 16 && n != 24 && n != 32 {
+			return errors.Errorf("encryption key must be 16, 24 or 32 bytes, got %d", n)
+		}
+	}
+
+	if tls := config.Cluster.TLS; tls != nil {
+		if tls.CertFile == "" || tls.KeyFile == "" || tls.CAFile == "" {
+			return errors.New("cert_file, key_file and ca_file are required by cluster tls")
+		}
+	}
+
+	if config.Tasks.Verdict.Quorum < 0 || config.Tasks.Verdict.Quorum > 1 {
+		return errors.New("verdict quorum must be between 0 and 1")
+	}
+
+	if d := config.Tasks.Mesh.Interval; d != 0 && d < targetpb.MinInterval {
+		return errors.Errorf("mesh interval must not be less than %s", targetpb.MinInterval)
+	}
+
+	if config.Tasks.TombstoneRetention < 0 {
+		return errors.New("tombstone retention cannot be negative")
+	}
+
+	buckets := config.Tasks.Histogram.Buckets
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return errors.New("histogram buckets must be in increasing order")
+		}
+	}
+
+	factor := config.Tasks.Histogram.NativeBucketFactor
+	if factor != 0 && factor <= 1 {
+		return errors.New("native bucket factor must be greater than 1")
+	}
+
 	return nil
 }
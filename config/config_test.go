@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/cluster"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterValid(t *testing.T) {
+	for name, c := range map[string]struct {
+		cluster Cluster
+		valid   bool
+	}{
+		"empty":          {Cluster{}, true},
+		"addrs":          {Cluster{BindAddr: "0.0.0.0:9094", AdvertiseAddr: ":9094"}, true},
+		"no port":        {Cluster{BindAddr: "0.0.0.0"}, false},
+		"invalid port":   {Cluster{AdvertiseAddr: "10.0.0.1:gossip"}, false},
+		"wan":            {Cluster{Preset: "wan"}, true},
+		"unknown preset": {Cluster{Preset: "dc"}, false},
+		"zstd":           {Cluster{Compression: "zstd"}, true},
+		"unknown compression": {
+			Cluster{Compression: "gzip"}, false,
+		},
+		"probe timeout": {
+			Cluster{ProbeInterval: 2 * time.Second, ProbeTimeout: 3 * time.Second}, false,
+		},
+		// checked once merged with the preset
+		"probe timeout of preset": {
+			Cluster{ProbeTimeout: 2 * time.Second}, false,
+		},
+		"probe interval of preset": {
+			Cluster{Preset: "wan", ProbeInterval: 2 * time.Second}, false,
+		},
+		"negative timing": {
+			Cluster{ReconnectTimeout: -time.Second}, false,
+		},
+	} {
+		err := c.cluster.valid()
+		if c.valid {
+			require.NoError(t, err, name)
+		} else {
+			require.Error(t, err, name)
+		}
+	}
+}
+
+func TestClusterTimings(t *testing.T) {
+	timings, err := (&Cluster{}).Timings()
+	require.NoError(t, err)
+	require.Equal(t, cluster.DefaultProbeInterval, timings.ProbeInterval)
+	require.Equal(t, cluster.DefaultProbeTimeout, timings.ProbeTimeout)
+
+	// the config overrides the preset
+	timings, err = (&Cluster{
+		Preset:         "wan",
+		GossipInterval: time.Second,
+		ProbeTimeout:   4 * time.Second,
+	}).Timings()
+	require.NoError(t, err)
+	require.Equal(t, time.Second, timings.GossipInterval)
+	require.Equal(t, 4*time.Second, timings.ProbeTimeout)
+	require.Equal(t, 5*time.Second, timings.ProbeInterval)
+
+	_, err = (&Cluster{Preset: "dc"}).Timings()
+	require.Error(t, err)
+}
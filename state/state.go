@@ -1,23 +1,38 @@
 package state
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/f1shl3gs/gossiping/tasks/targetpb"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	backupSuffix = ".yml.bak"
+
+	// gcInterval is the interval between two sweeps of backups
+	gcInterval = time.Hour
+)
+
 type Generator struct {
-	path   string
-	logger *zap.Logger
+	path      string
+	retention time.Duration
+	logger    *zap.Logger
 }
 
-func New(path string, logger *zap.Logger) *Generator {
+// New creates a generator of the state files of jobs in path, the backups
+// of deleted jobs are removed once their tombstones are purged, or they
+// are older than retention.
+func New(path string, retention time.Duration, logger *zap.Logger) *Generator {
 	return &Generator{
-		path:   path,
-		logger: logger,
+		path:      path,
+		retention: retention,
+		logger:    logger,
 	}
 }
 
@@ -38,6 +53,44 @@ func (gen *Generator) backup(me *targetpb.MeshEntry) {
 		gen.logger.Warn("state file saved failed",
 			zap.String("fn", fn),
 			zap.Error(err))
+		return
+	}
+
+	// rename keeps the modification time of the state file, but backups
+	// expire since the deletion
+	now := time.Now()
+	err = os.Chtimes(fn+".bak", now, now)
+	if err != nil {
+		gen.logger.Warn("touch backup failed",
+			zap.String("fn", fn),
+			zap.Error(err))
+	}
+}
+
+// Purge removes the backup of the entry, its tombstone is purged
+func (gen *Generator) Purge(me *targetpb.MeshEntry) {
+	fn := gen.filename(me) + ".bak"
+	err := os.Remove(fn)
+	if err != nil && !os.IsNotExist(err) {
+		gen.logger.Warn("remove backup failed",
+			zap.String("fn", fn),
+			zap.Error(err))
+	}
+}
+
+// Run removes the expired backups periodically till ctx is done
+func (gen *Generator) Run(ctx context.Context) {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+
+	for {
+		gen.gc()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -60,8 +113,39 @@ func (gen *Generator) update(me *targetpb.MeshEntry) {
 	}
 }
 
+// gc removes the backups older than the retention, e.g. the ones whose
+// tombstones are gone with a restart
 func (gen *Generator) gc() {
+	entries, err := os.ReadDir(gen.path)
+	if err != nil {
+		gen.logger.Warn("read state dir failed",
+			zap.String("dir", gen.path),
+			zap.Error(err))
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), backupSuffix) {
+			continue
+		}
 
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) <= gen.retention {
+			continue
+		}
+
+		fn := filepath.Join(gen.path, entry.Name())
+		err = os.Remove(fn)
+		if err != nil {
+			gen.logger.Warn("remove backup failed",
+				zap.String("fn", fn),
+				zap.Error(err))
+			continue
+		}
+
+		gen.logger.Debug("backup expired",
+			zap.String("fn", fn))
+	}
 }
 
 func (gen *Generator) filename(me *targetpb.MeshEntry) string {
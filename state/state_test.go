@@ -0,0 +1,66 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGenerator(t *testing.T) {
+	dir := t.TempDir()
+	gen := New(dir, time.Hour, zap.NewNop())
+
+	me := &targetpb.MeshEntry{
+		Name:   "job",
+		Status: targetpb.Status_Active,
+		Targetgroup: &targetpb.Targetgroup{
+			Targets: []string{"10.0.0.1"},
+		},
+	}
+	fn := filepath.Join(dir, "job.yml")
+	gen.OnUpdate(me)
+	data, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "10.0.0.1")
+
+	// the backup expires since the deletion, not the last update
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(fn, old, old))
+	gen.OnUpdate(&targetpb.MeshEntry{Name: "job", Status: targetpb.Status_Inactive})
+	require.NoFileExists(t, fn)
+	info, err := os.Stat(fn + ".bak")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+
+	gen.gc()
+	require.FileExists(t, fn+".bak")
+
+	// the tombstone is purged
+	gen.Purge(me)
+	require.NoFileExists(t, fn+".bak")
+	gen.Purge(me)
+}
+
+func TestGeneratorGC(t *testing.T) {
+	dir := t.TempDir()
+	gen := New(dir, time.Hour, zap.NewNop())
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"expired.yml.bak", "fresh.yml.bak", "active.yml"} {
+		fn := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(fn, nil, 0644))
+		if name != "fresh.yml.bak" {
+			require.NoError(t, os.Chtimes(fn, old, old))
+		}
+	}
+
+	gen.gc()
+	require.NoFileExists(t, filepath.Join(dir, "expired.yml.bak"))
+	require.FileExists(t, filepath.Join(dir, "fresh.yml.bak"))
+	require.FileExists(t, filepath.Join(dir, "active.yml"))
+}
@@ -0,0 +1,187 @@
+package tasks
+
+import (
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	dnsInterval = 5 * time.Second
+	dnsTimeout  = 2 * time.Second
+)
+
+// dnsProber sends the configured query to the target, which is a resolver.
+type dnsProber struct {
+	address  string
+	server   string
+	msg      *dns.Msg
+	interval time.Duration
+
+	client *dns.Client
+	stopc  chan struct{}
+
+	healthState
+
+	// metrics
+	lookups        prometheus.Counter
+	failures       prometheus.Counter
+	responses      *prometheus.CounterVec
+	answers        prometheus.Gauge
+	lookupDuration prometheus.Histogram
+}
+
+func newDNSProber(target Target) (Prober, error) {
+	addr := target.Address
+
+	name := target.Probe.GetDns().GetQueryName()
+	if name == "" {
+		return nil, errors.New("query name is required")
+	}
+
+	qtype := dns.TypeA
+	if s := target.Probe.GetDns().GetQueryType(); s != "" {
+		var ok bool
+		qtype, ok = dns.StringToType[s]
+		if !ok {
+			return nil, errors.Errorf("unknown query type %q", s)
+		}
+	}
+
+	// the port is optional for resolvers
+	server := addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		server = net.JoinHostPort(addr, "53")
+	}
+
+	msg := &dns.Msg{}
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+
+	constLabels := target.ConstLabels()
+
+	lookups := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "dns",
+		Name:        "lookups_total",
+		Help:        "The number of lookups",
+		ConstLabels: constLabels,
+	})
+
+	failures := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "dns",
+		Name:        "failures_total",
+		Help:        "The number of lookups that got no response",
+		ConstLabels: constLabels,
+	})
+
+	responses := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "dns",
+		Name:        "responses_total",
+		Help:        "The number of responses by rcode",
+		ConstLabels: constLabels,
+	}, []string{"rcode"})
+
+	answers := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "dns",
+		Name:        "answers",
+		Help:        "The number of answers in the last response",
+		ConstLabels: constLabels,
+	})
+
+	lookupDuration := prometheus.NewHistogram(target.HistogramOpts(prometheus.HistogramOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "dns",
+		Name:        "lookup_seconds",
+		Help:        "Time taken by the lookup",
+		ConstLabels: constLabels,
+	}))
+
+	return &dnsProber{
+		address:        addr,
+		server:         server,
+		msg:            msg,
+		interval:       target.Interval(dnsInterval),
+		client:         &dns.Client{Timeout: target.Timeout(dnsTimeout)},
+		stopc:          make(chan struct{}),
+		lookups:        lookups,
+		failures:       failures,
+		responses:      responses,
+		answers:        answers,
+		lookupDuration: lookupDuration,
+	}, nil
+}
+
+func (p *dnsProber) Describe(descs chan<- *prometheus.Desc) {
+	descs <- p.lookups.Desc()
+	descs <- p.failures.Desc()
+	p.responses.Describe(descs)
+	descs <- p.answers.Desc()
+	descs <- p.lookupDuration.Desc()
+}
+
+func (p *dnsProber) Collect(metrics chan<- prometheus.Metric) {
+	p.lookups.Collect(metrics)
+	p.failures.Collect(metrics)
+	p.responses.Collect(metrics)
+	p.answers.Collect(metrics)
+	p.lookupDuration.Collect(metrics)
+}
+
+func (p *dnsProber) Start(logger *zap.Logger) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			logger.Error("task panicked",
+				zap.Stack("task"))
+		}
+	}()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.lookup(logger)
+
+		select {
+		case <-p.stopc:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *dnsProber) lookup(logger *zap.Logger) {
+	p.lookups.Inc()
+
+	// every query needs an unique id
+	msg := p.msg.Copy()
+	msg.Id = dns.Id()
+
+	resp, rtt, err := p.client.Exchange(msg, p.server)
+	if err != nil {
+		p.setHealth(false)
+		p.failures.Inc()
+		// no response, the answers of the last one must not be reported
+		p.answers.Set(0)
+		logger.Debug("dns lookup failed",
+			zap.String("addr", p.address),
+			zap.Error(err))
+		return
+	}
+
+	p.setHealth(resp.Rcode == dns.RcodeSuccess)
+	p.lookupDuration.Observe(rtt.Seconds())
+	p.responses.WithLabelValues(dns.RcodeToString[resp.Rcode]).Inc()
+	p.answers.Set(float64(len(resp.Answer)))
+}
+
+func (p *dnsProber) Stop() {
+	close(p.stopc)
+}
@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDNSProber(t *testing.T) {
+	mux := dns.NewServeMux()
+	mux.HandleFunc("ok.example.", func(w dns.ResponseWriter, r *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(r)
+		for _, ip := range []string{"192.0.2.1", "192.0.2.2"} {
+			resp.Answer = append(resp.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP(ip),
+			})
+		}
+		w.WriteMsg(resp)
+	})
+	mux.HandleFunc("missing.example.", func(w dns.ResponseWriter, r *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetRcode(r, dns.RcodeNameError)
+		w.WriteMsg(resp)
+	})
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	srv := &dns.Server{PacketConn: pc, Handler: mux, NotifyStartedFunc: func() { close(started) }}
+	go srv.ActivateAndServe()
+	<-started
+
+	newProber := func(name string) *dnsProber {
+		prober, err := newDNSProber(Target{
+			Address: pc.LocalAddr().String(),
+			Probe: &targetpb.Probe{
+				Type:    "dns",
+				Dns:     &targetpb.DNSProbe{QueryName: name},
+				Timeout: 200 * time.Millisecond,
+			},
+		})
+		require.NoError(t, err)
+		return prober.(*dnsProber)
+	}
+
+	p := newProber("ok.example")
+	p.lookup(zap.NewNop())
+	require.Equal(t, HealthUp, p.Health())
+	require.Equal(t, float64(2), testutil.ToFloat64(p.answers))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.responses.WithLabelValues("NOERROR")))
+
+	missing := newProber("missing.example")
+	missing.lookup(zap.NewNop())
+	require.Equal(t, HealthDown, missing.Health())
+	require.Equal(t, float64(0), testutil.ToFloat64(missing.answers))
+	require.Equal(t, float64(1), testutil.ToFloat64(missing.responses.WithLabelValues("NXDOMAIN")))
+
+	// no response, the answers of the last one are reset
+	require.NoError(t, srv.Shutdown())
+	p.lookup(zap.NewNop())
+	require.Equal(t, HealthDown, p.Health())
+	require.Equal(t, float64(0), testutil.ToFloat64(p.answers))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.failures))
+
+	_, err = newDNSProber(Target{Address: "127.0.0.1", Probe: &targetpb.Probe{Type: "dns"}})
+	require.Error(t, err)
+	_, err = newDNSProber(Target{Address: "127.0.0.1", Probe: &targetpb.Probe{
+		Type: "dns",
+		Dns:  &targetpb.DNSProbe{QueryName: "ok.example", QueryType: "BOGUS"},
+	}})
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package tasks
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+)
+
+// logicalBits is the number of the low bits of timestamps which count the
+// events within the same millisecond
+const logicalBits = 16
+
+// Clock is a hybrid logical clock, its timestamps never go backwards, and
+// they are greater than any timestamp observed, so an edit made after
+// another one is seen always wins, no matter how skewed the wall clocks are.
+type Clock struct {
+	mtx  sync.Mutex
+	last int64
+	now  func() time.Time
+}
+
+func NewClock() *Clock {
+	return &Clock{now: time.Now}
+}
+
+// Now returns a timestamp greater than all the previous ones
+func (c *Clock) Now() int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	pt := physical(c.now())
+	if pt > c.last {
+		c.last = pt
+	} else {
+		c.last++
+	}
+
+	return c.last
+}
+
+// Observe advances the clock to the timestamp received from other nodes
+func (c *Clock) Observe(ts int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if ts > c.last {
+		c.last = ts
+	}
+}
+
+func physical(t time.Time) int64 {
+	return t.UnixMilli() << logicalBits
+}
+
+// migrate versions the entries written by nodes without clocks by their
+// update time, they lose to the entries written in the same millisecond
+// by nodes with clocks, since their node is empty.
+func migrate(me *targetpb.MeshEntry) {
+	if me.Version.Hlc != 0 {
+		return
+	}
+
+	me.Version = targetpb.Version{Hlc: physical(me.Updated)}
+}
+
+// compareVersions returns -1, 0 or 1 if a is older than, equal to or newer
+// than b
+func compareVersions(a, b targetpb.Version) int {
+	switch {
+	case a.Hlc < b.Hlc:
+		return -1
+	case a.Hlc > b.Hlc:
+		return 1
+	default:
+		return strings.Compare(a.Node, b.Node)
+	}
+}
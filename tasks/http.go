@@ -0,0 +1,301 @@
+package tasks
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	httpInterval = 5 * time.Second
+	httpTimeout  = 5 * time.Second
+
+	// the body is read up to this size when matching it
+	maxBodySize = 1 << 20
+)
+
+// httpProber requests the target URL and measures every phase of the request
+type httpProber struct {
+	address   string
+	bodyRegex *regexp.Regexp
+	interval  time.Duration
+
+	client *http.Client
+	stopc  chan struct{}
+
+	healthState
+
+	// metrics
+	requests  prometheus.Counter
+	failures  prometheus.Counter
+	durations *prometheus.HistogramVec
+	status    prometheus.Gauge
+	bodyMatch prometheus.Gauge
+}
+
+func newHTTPProber(target Target) (Prober, error) {
+	addr := target.Address
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	var bodyRegex *regexp.Regexp
+	if expr := target.Probe.GetHttp().GetBodyRegex(); expr != "" {
+		bodyRegex, err = regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	constLabels := target.ConstLabels()
+
+	requests := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "http",
+		Name:        "requests_total",
+		Help:        "The number of requests",
+		ConstLabels: constLabels,
+	})
+
+	failures := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "http",
+		Name:        "failures_total",
+		Help:        "The number of requests that got no response",
+		ConstLabels: constLabels,
+	})
+
+	durations := prometheus.NewHistogramVec(target.HistogramOpts(prometheus.HistogramOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "http",
+		Name:        "duration_seconds",
+		Help:        "Duration of the request by phase",
+		ConstLabels: constLabels,
+	}), []string{"phase"})
+
+	status := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "http",
+		Name:        "status_code",
+		Help:        "Status code of the last response",
+		ConstLabels: constLabels,
+	})
+
+	bodyMatch := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "http",
+		Name:        "body_match",
+		Help:        "Whether the body of the last response matched the regex",
+		ConstLabels: constLabels,
+	})
+
+	return &httpProber{
+		address:   addr,
+		bodyRegex: bodyRegex,
+		interval:  target.Interval(httpInterval),
+		client: &http.Client{
+			Timeout: target.Timeout(httpTimeout),
+			Transport: &http.Transport{
+				// every request should dial and handshake again,
+				// otherwise those phases can not be measured
+				DisableKeepAlives: true,
+			},
+		},
+		stopc:     make(chan struct{}),
+		requests:  requests,
+		failures:  failures,
+		durations: durations,
+		status:    status,
+		bodyMatch: bodyMatch,
+	}, nil
+}
+
+func (p *httpProber) Describe(descs chan<- *prometheus.Desc) {
+	descs <- p.requests.Desc()
+	descs <- p.failures.Desc()
+	p.durations.Describe(descs)
+	descs <- p.status.Desc()
+	if p.bodyRegex != nil {
+		descs <- p.bodyMatch.Desc()
+	}
+}
+
+func (p *httpProber) Collect(metrics chan<- prometheus.Metric) {
+	p.requests.Collect(metrics)
+	p.failures.Collect(metrics)
+	p.durations.Collect(metrics)
+	p.status.Collect(metrics)
+	if p.bodyRegex != nil {
+		p.bodyMatch.Collect(metrics)
+	}
+}
+
+func (p *httpProber) Start(logger *zap.Logger) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			logger.Error("task panicked",
+				zap.Stack("task"))
+		}
+	}()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		err := p.probe()
+		if err != nil {
+			logger.Debug("http request failed",
+				zap.String("addr", p.address),
+				zap.Error(err))
+		}
+
+		select {
+		case <-p.stopc:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// phases records the timestamps of a request, hooks of httptrace might be
+// called concurrently, e.g. dialing both IPv4 and IPv6 address.
+type phases struct {
+	mtx sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+}
+
+func (p *phases) set(t *time.Time) {
+	p.mtx.Lock()
+	*t = time.Now()
+	p.mtx.Unlock()
+}
+
+func (p *phases) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { p.set(&p.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { p.set(&p.dnsDone) },
+		ConnectStart: func(_, _ string) {
+			p.set(&p.connectStart)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				p.set(&p.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { p.set(&p.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				p.set(&p.tlsDone)
+			}
+		},
+		GotFirstResponseByte: func() { p.set(&p.firstByte) },
+	}
+}
+
+// probe requests the target once, the status code and the body match of
+// failed requests are reset, so the values of the last response are not
+// reported while the target is down.
+func (p *httpProber) probe() error {
+	err := p.request()
+	if err != nil {
+		p.setHealth(false)
+		p.failures.Inc()
+		p.status.Set(0)
+		p.bodyMatch.Set(0)
+	}
+
+	return err
+}
+
+func (p *httpProber) request() error {
+	p.requests.Inc()
+
+	ph := &phases{start: time.Now()}
+	req, err := http.NewRequest(http.MethodGet, p.address, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), ph.trace()))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// the target is up if it responds without errors, and the body
+	// matches the regex if any
+	up := resp.StatusCode < http.StatusBadRequest
+	if p.bodyRegex != nil {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		if err != nil {
+			return err
+		}
+
+		if p.bodyRegex.Match(body) {
+			p.bodyMatch.Set(1)
+		} else {
+			up = false
+			p.bodyMatch.Set(0)
+		}
+	}
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	total := time.Since(ph.start)
+	p.setHealth(up)
+
+	ph.mtx.Lock()
+	defer ph.mtx.Unlock()
+
+	p.status.Set(float64(resp.StatusCode))
+	p.observe("dns", ph.dnsStart, ph.dnsDone)
+	p.observe("connect", ph.connectStart, ph.connectDone)
+	p.observe("tls", ph.tlsStart, ph.tlsDone)
+	p.observe("ttfb", ph.start, ph.firstByte)
+	p.durations.WithLabelValues("total").Observe(total.Seconds())
+
+	return nil
+}
+
+// observe records the phase only if it did happen, e.g. there is
+// no DNS phase if the target is an IP address
+func (p *httpProber) observe(phase string, start, end time.Time) {
+	if start.IsZero() || end.IsZero() {
+		return
+	}
+
+	p.durations.WithLabelValues(phase).Observe(end.Sub(start).Seconds())
+}
+
+func (p *httpProber) Stop() {
+	close(p.stopc)
+}
@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProber(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte("status: ok"))
+	}))
+	defer srv.Close()
+
+	newProber := func(regex string) *httpProber {
+		prober, err := newHTTPProber(Target{
+			Address: srv.URL,
+			Probe: &targetpb.Probe{
+				Type: "http",
+				Http: &targetpb.HTTPProbe{BodyRegex: regex},
+			},
+		})
+		require.NoError(t, err)
+		return prober.(*httpProber)
+	}
+
+	p := newProber("status: (ok|degraded)")
+	require.NoError(t, p.probe())
+	require.Equal(t, HealthUp, p.Health())
+	require.Equal(t, float64(http.StatusOK), testutil.ToFloat64(p.status))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.bodyMatch))
+
+	// the body doesn't match
+	p = newProber("status: down")
+	require.NoError(t, p.probe())
+	require.Equal(t, HealthDown, p.Health())
+	require.Equal(t, float64(0), testutil.ToFloat64(p.bodyMatch))
+
+	// error responses are down
+	status = http.StatusServiceUnavailable
+	p = newProber("")
+	require.NoError(t, p.probe())
+	require.Equal(t, HealthDown, p.Health())
+	require.Equal(t, float64(http.StatusServiceUnavailable), testutil.ToFloat64(p.status))
+
+	// no response, the values of the last one are reset
+	status = http.StatusOK
+	p = newProber("ok")
+	require.NoError(t, p.probe())
+	require.Equal(t, float64(1), testutil.ToFloat64(p.bodyMatch))
+	srv.Close()
+	require.Error(t, p.probe())
+	require.Equal(t, HealthDown, p.Health())
+	require.Equal(t, float64(0), testutil.ToFloat64(p.status))
+	require.Equal(t, float64(0), testutil.ToFloat64(p.bodyMatch))
+	require.Equal(t, float64(1), testutil.ToFloat64(p.failures))
+	require.Equal(t, float64(2), testutil.ToFloat64(p.requests))
+
+	_, err := newHTTPProber(Target{Address: "ftp://example.com"})
+	require.Error(t, err)
+}
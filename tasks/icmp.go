@@ -0,0 +1,495 @@
+package tasks
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// pinger is a target registered to an icmpEngine, the engine sends echo
+// requests on its behalf and dispatches replies to it by the ICMP id.
+// The id is carried by the payload too, because the kernel replaces it
+// with its own for datagram sockets.
+type pinger struct {
+	ip       net.IP
+	interval time.Duration
+	size     int
+	stats    *pingStats
+
+	onSend  func()
+	onRecv  func(rtt time.Duration)
+	onError func(err error)
+
+	// guarded by the engine
+	id    uint16
+	next  time.Time
+	index int
+}
+
+func newPinger(ip net.IP, interval time.Duration, size int, stats *pingStats) *pinger {
+	return &pinger{
+		ip:       ip,
+		interval: interval,
+		size:     size,
+		stats:    stats,
+		onSend:   func() {},
+		onRecv:   func(time.Duration) {},
+		onError:  func(error) {},
+	}
+}
+
+// pingQueue orders pingers by the time of their next echo request
+type pingQueue []*pinger
+
+func (q pingQueue) Len() int { return len(q) }
+
+func (q pingQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q pingQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *pingQueue) Push(x interface{}) {
+	p := x.(*pinger)
+	p.index = len(*q)
+	*q = append(*q, p)
+}
+
+func (q *pingQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	p := old[n-1]
+	old[n-1] = nil
+	p.index = -1
+	*q = old[:n-1]
+	return p
+}
+
+// idSpace allocates ICMP ids. A raw socket receives all echo replies of
+// its address family, so the engines of a family share one, otherwise
+// engines of different socket options, e.g. TOS, might pick the same id
+// and take the replies of each other.
+type idSpace struct {
+	mtx  sync.Mutex
+	next uint16
+	used map[uint16]struct{}
+}
+
+func newIDSpace() *idSpace {
+	return &idSpace{
+		next: uint16(rand.Intn(1 << 16)),
+		used: make(map[uint16]struct{}),
+	}
+}
+
+func (s *idSpace) alloc() (uint16, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(s.used) >= 1<<16 {
+		return 0, errors.New("no ICMP id available")
+	}
+
+	for {
+		s.next++
+		if _, used := s.used[s.next]; !used {
+			break
+		}
+	}
+
+	s.used[s.next] = struct{}{}
+	return s.next, nil
+}
+
+func (s *idSpace) release(id uint16) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.used, id)
+}
+
+// engineKey identifies the socket an engine owns, pingers share an engine
+// only if they need the same socket options.
+type engineKey struct {
+	v6           bool
+	tos          uint8
+	dontFragment bool
+}
+
+// icmpEngine multiplexes the pingers of an address family over one
+// socket, so the number of sockets and goroutines does not grow with
+// the number of targets.
+type icmpEngine struct {
+	logger *zap.Logger
+
+	conn      net.PacketConn
+	datagram  bool
+	protocol  int
+	echoType  icmp.Type
+	replyType icmp.Type
+
+	ids     *idSpace
+	mtx     sync.Mutex
+	pingers map[uint16]*pinger
+	queue   pingQueue
+	wakeup  chan struct{}
+}
+
+func newICMPEngine(logger *zap.Logger, key engineKey, ids *idSpace, datagram bool) (*icmpEngine, error) {
+	conn, err := listenICMP(key.v6, datagram)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setSocketOptions(conn, key)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	e := newEngine(logger, conn, ids, key.v6, datagram)
+
+	go e.receive()
+	go e.schedule()
+
+	return e, nil
+}
+
+// newEngine creates an engine of the socket, it's not started
+func newEngine(logger *zap.Logger, conn net.PacketConn, ids *idSpace, v6, datagram bool) *icmpEngine {
+	protocol, echoType, replyType := protocolICMP, icmp.Type(ipv4.ICMPTypeEcho), icmp.Type(ipv4.ICMPTypeEchoReply)
+	if v6 {
+		protocol, echoType, replyType = protocolIPv6ICMP, ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+
+	return &icmpEngine{
+		logger:    logger,
+		conn:      conn,
+		datagram:  datagram,
+		protocol:  protocol,
+		echoType:  echoType,
+		replyType: replyType,
+		ids:       ids,
+		pingers:   make(map[uint16]*pinger),
+		wakeup:    make(chan struct{}, 1),
+	}
+}
+
+// add registers the pinger, its first echo request is sent immediately
+func (e *icmpEngine) add(p *pinger) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	id, err := e.ids.alloc()
+	if err != nil {
+		return err
+	}
+
+	p.id = id
+	p.next = time.Now()
+	e.pingers[p.id] = p
+	heap.Push(&e.queue, p)
+
+	select {
+	case e.wakeup <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (e *icmpEngine) remove(p *pinger) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if e.pingers[p.id] != p {
+		return
+	}
+
+	delete(e.pingers, p.id)
+	heap.Remove(&e.queue, p.index)
+	e.ids.release(p.id)
+}
+
+// schedule sends echo requests of all pingers when they are due
+func (e *icmpEngine) schedule() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	var due []*pinger
+	for {
+		var wait time.Duration
+		due, wait = e.due(time.Now(), due[:0])
+
+		for _, p := range due {
+			e.send(p)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-timer.C:
+		case <-e.wakeup:
+		}
+	}
+}
+
+// due appends the pingers due at now to buf, and reschedules them, the
+// time until the next one is due is returned too.
+func (e *icmpEngine) due(now time.Time, buf []*pinger) ([]*pinger, time.Duration) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	for len(e.queue) > 0 && !e.queue[0].next.After(now) {
+		p := e.queue[0]
+		buf = append(buf, p)
+
+		p.next = p.next.Add(p.interval)
+		if p.next.Before(now) {
+			// we are too far behind, skip the missed ones
+			p.next = now.Add(p.interval)
+		}
+		heap.Fix(&e.queue, 0)
+	}
+
+	wait := time.Hour
+	if len(e.queue) > 0 {
+		wait = e.queue[0].next.Sub(now)
+	}
+
+	return buf, wait
+}
+
+// send sends an echo request of the pinger, the time is taken per request
+// right before it's written, so targets due together don't get the delay
+// of writing the requests before them added to their rtt.
+func (e *icmpEngine) send(p *pinger) {
+	data := make([]byte, p.size)
+	binary.BigEndian.PutUint16(data, p.id)
+
+	seq := p.stats.sent(time.Now())
+	msg := icmp.Message{
+		Type: e.echoType,
+		Body: &icmp.Echo{
+			ID:   int(p.id),
+			Seq:  int(seq),
+			Data: data,
+		},
+	}
+
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		p.onError(err)
+		return
+	}
+
+	var dst net.Addr = &net.IPAddr{IP: p.ip}
+	if e.datagram {
+		dst = &net.UDPAddr{IP: p.ip}
+	}
+
+	_, err = e.conn.WriteTo(b, dst)
+	if err != nil {
+		p.onError(err)
+		return
+	}
+
+	p.onSend()
+}
+
+// receive reads replies and dispatches them to the pingers
+func (e *icmpEngine) receive() {
+	buf := make([]byte, 1<<16)
+	for {
+		n, peer, err := e.conn.ReadFrom(buf)
+		if err != nil {
+			e.logger.Warn("read ICMP message failed",
+				zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		now := time.Now()
+		msg, err := icmp.ParseMessage(e.protocol, buf[:n])
+		if err != nil || msg.Type != e.replyType {
+			continue
+		}
+
+		echo, ok := msg.Body.(*icmp.Echo)
+		if !ok {
+			continue
+		}
+
+		id := uint16(echo.ID)
+		if e.datagram {
+			if len(echo.Data) < 2 {
+				continue
+			}
+			id = binary.BigEndian.Uint16(echo.Data)
+		}
+
+		e.mtx.Lock()
+		p := e.pingers[id]
+		e.mtx.Unlock()
+
+		// the raw socket receives replies of other processes too
+		if p == nil || !peerIP(peer).Equal(p.ip) {
+			continue
+		}
+
+		rtt, ok := p.stats.received(uint16(echo.Seq), now)
+		if ok {
+			p.onRecv(rtt)
+		}
+	}
+}
+
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	default:
+		return nil
+	}
+}
+
+// listenICMP opens a raw socket, which needs root privileges or
+// CAP_NET_RAW, or a datagram socket which needs the gid of the process
+// to be in the range of sysctl net.ipv4.ping_group_range.
+func listenICMP(v6 bool, datagram bool) (*icmp.PacketConn, error) {
+	network, address := icmpNetwork(v6, datagram)
+	conn, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen %s", network)
+	}
+
+	return conn, nil
+}
+
+// icmpNetwork returns the network and the address to listen on
+func icmpNetwork(v6 bool, datagram bool) (string, string) {
+	switch {
+	case !v6 && !datagram:
+		return "ip4:icmp", "0.0.0.0"
+	case !v6 && datagram:
+		return "udp4", "0.0.0.0"
+	case v6 && !datagram:
+		return "ip6:ipv6-icmp", "::"
+	default:
+		return "udp6", "::"
+	}
+}
+
+// setSocketOptions applies the TOS(traffic class for IPv6) and the
+// don't fragment option to the socket
+func setSocketOptions(conn *icmp.PacketConn, key engineKey) error {
+	var (
+		pc  net.PacketConn
+		err error
+	)
+
+	if key.v6 {
+		p := conn.IPv6PacketConn()
+		if key.tos != 0 {
+			err = p.SetTrafficClass(int(key.tos))
+		}
+		pc = p.PacketConn
+	} else {
+		p := conn.IPv4PacketConn()
+		if key.tos != 0 {
+			err = p.SetTOS(int(key.tos))
+		}
+		pc = p.PacketConn
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "set tos")
+	}
+
+	if key.dontFragment {
+		err = setDontFragment(pc, key.v6)
+		if err != nil {
+			return errors.Wrap(err, "set don't fragment")
+		}
+	}
+
+	return nil
+}
+
+var (
+	enginesMtx sync.Mutex
+	engines    = map[engineKey]*icmpEngine{}
+	// the id spaces of IPv4 and IPv6
+	idSpaces     = map[bool]*idSpace{false: newIDSpace(), true: newIDSpace()}
+	unprivileged bool
+)
+
+// UseUnprivilegedICMP makes ping probers use ICMP datagram sockets, so
+// gossiping can run as a normal user. It must be called before any job
+// is coordinated, an error is returned if the host does not allow the
+// process to open such sockets.
+func UseUnprivilegedICMP() error {
+	enginesMtx.Lock()
+	defer enginesMtx.Unlock()
+
+	conn, err := listenICMP(false, true)
+	if err != nil {
+		return errors.Wrapf(err, "unprivileged ICMP is not permitted for gid %d, "+
+			"add it to sysctl net.ipv4.ping_group_range", os.Getgid())
+	}
+
+	unprivileged = true
+	return conn.Close()
+}
+
+// getEngine returns the engine of the address family of ip and the
+// socket options, it is created on the first call, and lives as long
+// as the process.
+func getEngine(logger *zap.Logger, ip net.IP, tos uint8, dontFragment bool) (*icmpEngine, error) {
+	key := engineKey{
+		v6:           ip.To4() == nil,
+		tos:          tos,
+		dontFragment: dontFragment,
+	}
+
+	enginesMtx.Lock()
+	defer enginesMtx.Unlock()
+
+	e := engines[key]
+	if e != nil {
+		return e, nil
+	}
+
+	e, err := newICMPEngine(logger, key, idSpaces[key.v6], unprivileged)
+	if err != nil {
+		return nil, err
+	}
+	engines[key] = e
+
+	return e, nil
+}
@@ -0,0 +1,262 @@
+package tasks
+
+import (
+	"container/heap"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+type packet struct {
+	data []byte
+	addr net.Addr
+}
+
+// fakeConn records the packets written, and returns the packets fed to
+// reads
+type fakeConn struct {
+	writes chan packet
+	reads  chan packet
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		writes: make(chan packet, 16),
+		reads:  make(chan packet),
+	}
+}
+
+func (c *fakeConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	p := <-c.reads
+	return copy(b, p.data), p.addr, nil
+}
+
+func (c *fakeConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.writes <- packet{data: append([]byte(nil), b...), addr: addr}
+	return len(b), nil
+}
+
+func (c *fakeConn) Close() error                       { return nil }
+func (c *fakeConn) LocalAddr() net.Addr                { return &net.IPAddr{} }
+func (c *fakeConn) SetDeadline(_ time.Time) error      { return nil }
+func (c *fakeConn) SetReadDeadline(_ time.Time) error  { return nil }
+func (c *fakeConn) SetWriteDeadline(_ time.Time) error { return nil }
+
+// written returns the echo request written to the conn
+func (c *fakeConn) written(t *testing.T) (*icmp.Echo, net.Addr) {
+	select {
+	case p := <-c.writes:
+		msg, err := icmp.ParseMessage(protocolICMP, p.data)
+		require.NoError(t, err)
+		require.Equal(t, ipv4.ICMPTypeEcho, msg.Type)
+		return msg.Body.(*icmp.Echo), p.addr
+	case <-time.After(time.Second):
+		t.Fatal("no echo request written")
+		return nil, nil
+	}
+}
+
+// reply feeds the echo reply of the request from addr
+func (c *fakeConn) reply(t *testing.T, echo *icmp.Echo, addr net.Addr) {
+	msg := icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: echo}
+	b, err := msg.Marshal(nil)
+	require.NoError(t, err)
+
+	c.reads <- packet{data: b, addr: addr}
+}
+
+func testPinger(ip string) (*pinger, chan time.Duration) {
+	replies := make(chan time.Duration, 16)
+	p := newPinger(net.ParseIP(ip), time.Hour, 8, newPingStats(time.Minute, 10))
+	p.onRecv = func(rtt time.Duration) { replies <- rtt }
+	return p, replies
+}
+
+func TestICMPEngineDispatch(t *testing.T) {
+	conn := newFakeConn()
+	e := newEngine(zap.NewNop(), conn, newIDSpace(), false, false)
+	go e.receive()
+	go e.schedule()
+
+	a, aReplies := testPinger("192.0.2.1")
+	b, bReplies := testPinger("192.0.2.2")
+	require.NoError(t, e.add(a))
+	require.NoError(t, e.add(b))
+	require.NotEqual(t, a.id, b.id)
+
+	requests := map[string]*icmp.Echo{}
+	for i := 0; i < 2; i++ {
+		echo, addr := conn.written(t)
+		requests[addr.String()] = echo
+	}
+	require.Equal(t, int(a.id), requests["192.0.2.1"].ID)
+	require.Equal(t, int(b.id), requests["192.0.2.2"].ID)
+
+	// replies of other hosts, or of unknown ids are ignored
+	conn.reply(t, requests["192.0.2.1"], &net.IPAddr{IP: net.ParseIP("192.0.2.2")})
+	unknown := *requests["192.0.2.1"]
+	unknown.ID = int(b.id + 1)
+	conn.reply(t, &unknown, &net.IPAddr{IP: a.ip})
+
+	conn.reply(t, requests["192.0.2.2"], &net.IPAddr{IP: b.ip})
+	conn.reply(t, requests["192.0.2.1"], &net.IPAddr{IP: a.ip})
+	for _, replies := range []chan time.Duration{aReplies, bReplies} {
+		select {
+		case <-replies:
+		case <-time.After(time.Second):
+			t.Fatal("reply not dispatched")
+		}
+	}
+	require.Empty(t, aReplies)
+	require.Empty(t, bReplies)
+
+	// removed pingers get nothing
+	e.remove(a)
+	conn.reply(t, requests["192.0.2.1"], &net.IPAddr{IP: a.ip})
+	// reads are unbuffered, the previous reply is handled once it's read
+	conn.reply(t, requests["192.0.2.2"], &net.IPAddr{IP: b.ip})
+	require.Empty(t, aReplies)
+}
+
+func TestICMPEngineDatagram(t *testing.T) {
+	conn := newFakeConn()
+	e := newEngine(zap.NewNop(), conn, newIDSpace(), false, true)
+	go e.receive()
+	go e.schedule()
+
+	p, replies := testPinger("192.0.2.1")
+	require.NoError(t, e.add(p))
+
+	echo, addr := conn.written(t)
+	require.IsType(t, &net.UDPAddr{}, addr)
+
+	// the kernel replaces the id of datagram sockets, the one in the
+	// payload is used
+	echo.ID = int(p.id + 1)
+	conn.reply(t, echo, &net.UDPAddr{IP: p.ip})
+	select {
+	case <-replies:
+	case <-time.After(time.Second):
+		t.Fatal("reply not dispatched by the id in the payload")
+	}
+}
+
+func TestICMPEnginesShareIDs(t *testing.T) {
+	// engines of different TOS have their own raw sockets, and both of
+	// them receive every echo reply
+	ids := newIDSpace()
+	lowConn, highConn := newFakeConn(), newFakeConn()
+	low := newEngine(zap.NewNop(), lowConn, ids, false, false)
+	high := newEngine(zap.NewNop(), highConn, ids, false, false)
+	for _, e := range []*icmpEngine{low, high} {
+		go e.receive()
+		go e.schedule()
+	}
+
+	a, aReplies := testPinger("192.0.2.1")
+	b, bReplies := testPinger("192.0.2.1")
+	require.NoError(t, low.add(a))
+	require.NoError(t, high.add(b))
+	require.NotEqual(t, a.id, b.id)
+
+	aEcho, addr := lowConn.written(t)
+	bEcho, _ := highConn.written(t)
+	for _, conn := range []*fakeConn{lowConn, highConn} {
+		conn.reply(t, aEcho, addr)
+		conn.reply(t, bEcho, addr)
+	}
+	// reads are unbuffered, the previous replies are handled once they're read
+	lowConn.reply(t, aEcho, addr)
+	highConn.reply(t, bEcho, addr)
+
+	for _, replies := range []chan time.Duration{aReplies, bReplies} {
+		require.Len(t, replies, 1)
+	}
+
+	// ids are released on removal
+	low.remove(a)
+	high.remove(b)
+	require.Empty(t, ids.used)
+}
+
+func TestICMPEngineDue(t *testing.T) {
+	e := newEngine(zap.NewNop(), newFakeConn(), newIDSpace(), false, false)
+	now := time.Now()
+
+	fast, _ := testPinger("192.0.2.1")
+	fast.interval = time.Second
+	slow, _ := testPinger("192.0.2.2")
+	slow.interval = time.Minute
+	require.NoError(t, e.add(slow))
+	require.NoError(t, e.add(fast))
+	slow.next = now
+	fast.next = now.Add(-time.Millisecond)
+	heap.Init(&e.queue)
+
+	// both are due, the earliest first
+	due, wait := e.due(now, nil)
+	require.Equal(t, []*pinger{fast, slow}, due)
+	require.Equal(t, now.Add(time.Second-time.Millisecond), fast.next)
+	require.Equal(t, now.Add(time.Minute), slow.next)
+	require.Equal(t, time.Second-time.Millisecond, wait)
+
+	due, wait = e.due(now.Add(500*time.Millisecond), nil)
+	require.Empty(t, due)
+	require.Equal(t, 500*time.Millisecond-time.Millisecond, wait)
+
+	// far behind, the missed ones are skipped
+	late := now.Add(10 * time.Second)
+	due, _ = e.due(late, nil)
+	require.Equal(t, []*pinger{fast}, due)
+	require.Equal(t, late.Add(time.Second), fast.next)
+
+	e.remove(fast)
+	e.remove(slow)
+	due, wait = e.due(late.Add(time.Hour), nil)
+	require.Empty(t, due)
+	require.Equal(t, time.Hour, wait)
+}
+
+func TestICMPNetwork(t *testing.T) {
+	for _, c := range []struct {
+		v6, datagram     bool
+		network, address string
+	}{
+		{false, false, "ip4:icmp", "0.0.0.0"},
+		{false, true, "udp4", "0.0.0.0"},
+		{true, false, "ip6:ipv6-icmp", "::"},
+		{true, true, "udp6", "::"},
+	} {
+		network, address := icmpNetwork(c.v6, c.datagram)
+		require.Equal(t, c.network, network)
+		require.Equal(t, c.address, address)
+	}
+}
+
+func TestUseUnprivilegedICMP(t *testing.T) {
+	defer func() {
+		unprivileged = false
+	}()
+
+	err := UseUnprivilegedICMP()
+	if err != nil {
+		// the host doesn't allow it, the error tells how to fix it
+		require.Contains(t, err.Error(), "net.ipv4.ping_group_range")
+		require.False(t, unprivileged)
+		return
+	}
+	require.True(t, unprivileged)
+
+	// datagram sockets work without privileges
+	conn, err := listenICMP(false, true)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, ok := conn.LocalAddr().(*net.UDPAddr)
+	require.True(t, ok)
+}
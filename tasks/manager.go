@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/f1shl3gs/gossiping/tasks/targetpb"
@@ -9,19 +10,39 @@ import (
 	"go.uber.org/zap"
 )
 
+// task is a Prober running for a target of a job
+type task struct {
+	Target
+
+	prober Prober
+}
+
 type Collector struct {
 	logger         *zap.Logger
 	externalLabels map[string]string
+	histogram      *targetpb.Histogram
 
-	mtx   sync.RWMutex
-	tasks map[string]map[uint64]*Task
+	mtx     sync.RWMutex
+	tasks   map[string]map[uint64]*task
+	entries map[string]*targetpb.MeshEntry
+
+	// alive members of the cluster, targets are sharded among them
+	self    Member
+	members []Member
+
+	// jobs are stored but not probed while held
+	held bool
 }
 
-func New(logger *zap.Logger, externalLabels map[string]string) *Collector {
+// New creates a Collector, histogram is the layout of the latency histograms
+// of jobs that do not set their own, nil means the default layout.
+func New(logger *zap.Logger, externalLabels map[string]string, histogram *targetpb.Histogram) *Collector {
 	c := &Collector{
 		logger:         logger,
-		tasks:          make(map[string]map[uint64]*Task),
+		tasks:          make(map[string]map[uint64]*task),
+		entries:        make(map[string]*targetpb.MeshEntry),
 		externalLabels: externalLabels,
+		histogram:      histogram,
 	}
 
 	return c
@@ -37,24 +58,147 @@ func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
 
 	for _, group := range c.tasks {
 		for _, task := range group {
-			task.Collect(metrics)
+			task.prober.Collect(metrics)
+		}
+	}
+}
+
+// Health returns the health of the targets probed by this node, mesh
+// jobs are skipped since no other node probes the same targets.
+func (c *Collector) Health() []*targetpb.TargetHealth {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	var result []*targetpb.TargetHealth
+	for job, group := range c.tasks {
+		if strings.HasPrefix(job, meshJobPrefix) {
+			continue
+		}
+
+		for _, task := range group {
+			reporter, ok := task.prober.(HealthReporter)
+			if !ok {
+				continue
+			}
+
+			health := reporter.Health()
+			if health == HealthUnknown {
+				continue
+			}
+
+			result = append(result, &targetpb.TargetHealth{
+				Job:    job,
+				Target: task.Address,
+				Up:     health == HealthUp,
+			})
 		}
 	}
+
+	return result
 }
 
+// SetMembers updates the members of the cluster, and coordinates all jobs
+// again, so targets of jobs with replicas or node selectors are rebalanced.
+// The labels of self are attached to the metrics with the prefix "src_".
+func (c *Collector) SetMembers(self Member, members []Member) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.self = self
+	c.members = members
+
+	for _, me := range c.entries {
+		c.coordinate(me)
+	}
+}
+
+// Hold stops probing new jobs until Release, e.g. until the node has
+// received the jobs of the cluster.
+func (c *Collector) Hold() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.held = true
+}
+
+// Release starts probing the jobs received while held
+func (c *Collector) Release() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.held {
+		return
+	}
+
+	c.held = false
+	for _, me := range c.entries {
+		c.coordinate(me)
+	}
+}
+
+// Coordinate starts the probers of new targets and stops the probers
+// of the targets which are removed, all probers of the job are stopped
+// once the job is inactive. Entries named like the local mesh jobs are
+// ignored, so gossiped jobs never replace them.
 func (c *Collector) Coordinate(me *targetpb.MeshEntry) {
+	if isMeshJob(me.Name) {
+		c.logger.Warn("ignore job of reserved name",
+			zap.String("job", me.Name))
+		return
+	}
+
+	c.apply(me)
+}
+
+// apply coordinates the entry of any name, the mesh jobs are applied by it
+func (c *Collector) apply(me *targetpb.MeshEntry) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
+	if me.Status == targetpb.Status_Active {
+		c.entries[me.Name] = me
+	} else {
+		delete(c.entries, me.Name)
+	}
+
+	c.coordinate(me)
+}
+
+func (c *Collector) coordinate(me *targetpb.MeshEntry) {
+	if c.held {
+		return
+	}
+
 	taskGroup := c.tasks[me.Name]
 	if taskGroup == nil {
-		taskGroup = make(map[uint64]*Task)
+		taskGroup = make(map[uint64]*task)
 		c.tasks[me.Name] = taskGroup
 	}
 
+	var targets []string
+	probe := c.withDefaults(me.Targetgroup.GetProbe())
+	factory, ok := Lookup(probe.GetType())
+	if !ok {
+		c.logger.Warn("unknown probe type",
+			zap.String("job", me.Name),
+			zap.String("probe", probe.GetType()))
+	} else if me.Status == targetpb.Status_Active {
+		if err := me.Targetgroup.Validate(); err != nil {
+			c.logger.Warn("invalid job",
+				zap.String("job", me.Name),
+				zap.Error(err))
+		} else {
+			targets = me.Targetgroup.GetTargets()
+		}
+	}
+
 	// add task
-	idCache := make([]uint64, 0, len(me.Targetgroup.Targets))
-	for _, addr := range me.Targetgroup.Targets {
+	idCache := make(map[uint64]struct{}, len(targets))
+	for _, addr := range targets {
+		if !c.assigned(me.Targetgroup, addr) {
+			continue
+		}
+
 		m := make(map[string]string, len(me.Targetgroup.Labels)+len(c.externalLabels))
 		for k, v := range me.Targetgroup.Labels {
 			m[k] = v
@@ -62,68 +206,116 @@ func (c *Collector) Coordinate(me *targetpb.MeshEntry) {
 		for k, v := range c.externalLabels {
 			m[k] = v
 		}
+		for k, v := range c.self.Labels {
+			m["src_"+k] = v
+		}
 
-		taskID := TaskID(addr, m)
-		idCache = append(idCache, taskID)
+		taskID := TaskID(probe, addr, m)
+		idCache[taskID] = struct{}{}
 		_, ok := taskGroup[taskID]
 		if ok {
 			continue
 		}
 
-		task, err := newTask(addr, m)
+		target := Target{
+			Address: addr,
+			Probe:   probe,
+			Labels:  m,
+		}
+
+		prober, err := factory(target)
 		if err != nil {
 			c.logger.Warn("create new task failed",
 				zap.String("job", me.Name),
+				zap.String("probe", probe.GetType()),
 				zap.String("addr", addr),
 				zap.Error(err))
 			continue
 		}
 
-		go task.Start(c.logger)
+		go prober.Start(c.logger)
 
-		taskGroup[taskID] = task
+		taskGroup[taskID] = &task{
+			Target: target,
+			prober: prober,
+		}
 		c.logger.Info("add target",
 			zap.String("job", me.Name),
+			zap.String("probe", probe.GetType()),
 			zap.String("addr", addr))
 	}
 
 	// remote none exist
 	for taskID, task := range taskGroup {
-		found := false
-		for _, id := range idCache {
-			if id == taskID {
-				found = true
-				break
-			}
-		}
-
-		if found {
+		if _, found := idCache[taskID]; found {
 			continue
 		}
 
-		task.Stop()
+		task.prober.Stop()
 		delete(taskGroup, taskID)
 		c.logger.Info("delete target",
 			zap.String("job", me.Name),
-			zap.String("addr", task.address))
+			zap.String("addr", task.Address))
+	}
+
+	if len(taskGroup) == 0 {
+		delete(c.tasks, me.Name)
 	}
 }
 
+// assigned reports whether the target should be probed by this node, the
+// key must not depend on the configuration of the node, e.g. the external
+// labels, so all nodes agree on it. The node selector applies even if the
+// members are unknown, only the replicas are not sharded then.
+func (c *Collector) assigned(tg *targetpb.Targetgroup, addr string) bool {
+	if !c.self.matches(tg.NodeSelector) {
+		return false
+	}
+
+	if tg.Replicas == 0 || len(c.members) == 0 {
+		return true
+	}
+
+	key := TaskID(tg.Probe, addr, tg.Labels)
+	return assigned(key, c.self.Name, candidates(tg, c.self, c.members), int(tg.Replicas))
+}
+
+// withDefaults returns a copy of probe, the histogram layout not set
+// by the job is taken from the Collector.
+func (c *Collector) withDefaults(probe *targetpb.Probe) *targetpb.Probe {
+	if c.histogram == nil {
+		return probe
+	}
+
+	merged := targetpb.Probe{}
+	if probe != nil {
+		merged = *probe
+	}
+
+	histogram := targetpb.Histogram{}
+	if merged.Histogram != nil {
+		histogram = *merged.Histogram
+	}
+
+	if len(histogram.Buckets) == 0 {
+		histogram.Buckets = c.histogram.Buckets
+	}
+	if histogram.NativeBucketFactor == 0 {
+		histogram.NativeBucketFactor = c.histogram.NativeBucketFactor
+	}
+	merged.Histogram = &histogram
+
+	return &merged
+}
+
 // SeparatorByte is a byte that cannot occur in valid UTF-8 sequences and is
 // used to separate label names, label values, and other strings from each other
 // when calculating their combined hash value (aka signature aka fingerprint).
 const SeparatorByte byte = 255
 
-var (
-	// cache the signature of an empty label set.
-	emptyLabelSignature = hashNew()
-)
-
-func TaskID(addr string, labels map[string]string) uint64 {
-	if len(labels) == 0 {
-		return emptyLabelSignature
-	}
-
+// TaskID returns a fingerprint of the target, the probe configuration is
+// part of it, so tasks are recreated once their probe changes.
+func TaskID(probe *targetpb.Probe, addr string, labels map[string]string) uint64 {
 	labelNames := make([]string, 0, len(labels))
 	for labelName := range labels {
 		labelNames = append(labelNames, labelName)
@@ -143,5 +335,12 @@ func TaskID(addr string, labels map[string]string) uint64 {
 	sum = hashAdd(sum, addr)
 	sum = hashAddByte(sum, SeparatorByte)
 
+	sum = hashAdd(sum, "__probe__")
+	sum = hashAddByte(sum, SeparatorByte)
+	if probe != nil {
+		sum = hashAdd(sum, probe.String())
+	}
+	sum = hashAddByte(sum, SeparatorByte)
+
 	return sum
 }
@@ -0,0 +1,238 @@
+package tasks
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+const probeFake = "fake"
+
+type fakeProber struct {
+	target Target
+
+	once    sync.Once
+	stopc   chan struct{}
+	stopped chan struct{}
+
+	up prometheus.Gauge
+}
+
+var (
+	fakeMtx     sync.Mutex
+	fakeProbers = map[string]*fakeProber{}
+)
+
+func init() {
+	Register(probeFake, func(target Target) (Prober, error) {
+		p := &fakeProber{
+			target:  target,
+			stopc:   make(chan struct{}),
+			stopped: make(chan struct{}),
+			up: prometheus.NewGauge(prometheus.GaugeOpts{
+				Name:        "fake_up",
+				ConstLabels: target.ConstLabels(),
+			}),
+		}
+
+		fakeMtx.Lock()
+		fakeProbers[target.Address] = p
+		fakeMtx.Unlock()
+
+		return p, nil
+	})
+}
+
+func (p *fakeProber) Describe(descs chan<- *prometheus.Desc) {
+	descs <- p.up.Desc()
+}
+
+func (p *fakeProber) Collect(metrics chan<- prometheus.Metric) {
+	p.up.Collect(metrics)
+}
+
+func (p *fakeProber) Start(_ *zap.Logger) {
+	p.up.Set(1)
+	<-p.stopc
+	close(p.stopped)
+}
+
+func (p *fakeProber) Stop() {
+	p.once.Do(func() { close(p.stopc) })
+}
+
+func getFakeProber(t *testing.T, addr string) *fakeProber {
+	fakeMtx.Lock()
+	defer fakeMtx.Unlock()
+
+	p, ok := fakeProbers[addr]
+	require.True(t, ok, "prober of %s is not created", addr)
+	return p
+}
+
+func waitStopped(t *testing.T, p *fakeProber) {
+	select {
+	case <-p.stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("prober of %s is not stopped", p.target.Address)
+	}
+}
+
+func entry(name string, status targetpb.Status, targets ...string) *targetpb.MeshEntry {
+	return &targetpb.MeshEntry{
+		Name:    name,
+		Status:  status,
+		Updated: time.Now(),
+		Targetgroup: &targetpb.Targetgroup{
+			Targets: targets,
+			Labels:  map[string]string{"foo": "bar"},
+			Probe:   &targetpb.Probe{Type: probeFake},
+		},
+	}
+}
+
+func TestCoordinate(t *testing.T) {
+	c := New(zaptest.NewLogger(t), map[string]string{"az": "a"}, nil)
+
+	c.Coordinate(entry("job", targetpb.Status_Active, "a", "b"))
+	require.Len(t, c.tasks["job"], 2)
+
+	a := getFakeProber(t, "a")
+	require.Equal(t, map[string]string{"foo": "bar", "az": "a"}, a.target.Labels)
+
+	// remove b
+	b := getFakeProber(t, "b")
+	c.Coordinate(entry("job", targetpb.Status_Active, "a"))
+	require.Len(t, c.tasks["job"], 1)
+	waitStopped(t, b)
+	require.Same(t, a, getFakeProber(t, "a"))
+
+	// changing the probe restarts the task
+	changed := entry("job", targetpb.Status_Active, "a")
+	changed.Targetgroup.Probe.Http = &targetpb.HTTPProbe{BodyRegex: "ok"}
+	c.Coordinate(changed)
+	waitStopped(t, a)
+	require.NotSame(t, a, getFakeProber(t, "a"))
+
+	// deleted jobs carry no targetgroup
+	a = getFakeProber(t, "a")
+	c.Coordinate(&targetpb.MeshEntry{Name: "job", Status: targetpb.Status_Inactive})
+	waitStopped(t, a)
+	require.NotContains(t, c.tasks, "job")
+}
+
+func TestCoordinateReplicas(t *testing.T) {
+	c := New(zaptest.NewLogger(t), map[string]string{"az": "a"}, nil)
+
+	targets := []string{"r0", "r1", "r2", "r3", "r4", "r5", "r6", "r7"}
+	me := entry("job", targetpb.Status_Active, targets...)
+	me.Targetgroup.Replicas = 1
+
+	// all targets run before members are known
+	c.Coordinate(me)
+	require.Len(t, c.tasks["job"], len(targets))
+
+	self := Member{Name: "self"}
+	members := []Member{self, {Name: "x"}, {Name: "y"}}
+	c.SetMembers(self, members)
+	expected := 0
+	for _, addr := range targets {
+		key := TaskID(me.Targetgroup.Probe, addr, me.Targetgroup.Labels)
+		if assigned(key, "self", []string{"self", "x", "y"}, 1) {
+			expected++
+		}
+	}
+	require.Len(t, c.tasks["job"], expected)
+	require.Less(t, expected, len(targets))
+
+	// the others left
+	c.SetMembers(self, []Member{self})
+	require.Len(t, c.tasks["job"], len(targets))
+}
+
+func TestCoordinateNodeSelector(t *testing.T) {
+	c := New(zaptest.NewLogger(t), nil, nil)
+
+	self := Member{Name: "self", Labels: map[string]string{"zone": "a"}}
+	other := Member{Name: "other", Labels: map[string]string{"zone": "b"}}
+
+	// the selector applies before the members are known too
+	c.SetMembers(self, nil)
+	me := entry("job", targetpb.Status_Active, "s0", "s1", "s2", "s3")
+	me.Targetgroup.NodeSelector = map[string]string{"zone": "b"}
+	c.Coordinate(me)
+	require.NotContains(t, c.tasks, "job")
+
+	c.SetMembers(self, []Member{self, other})
+	require.NotContains(t, c.tasks, "job")
+
+	// one node per zone, self is the only one in zone a
+	me.Targetgroup.NodeSelector = nil
+	me.Targetgroup.Replicas = 1
+	me.Targetgroup.SpreadBy = "zone"
+	c.Coordinate(me)
+	require.Len(t, c.tasks["job"], 4)
+
+	p := getFakeProber(t, "s0")
+	require.Equal(t, "a", p.target.Labels["src_zone"])
+}
+
+func TestCoordinateUnknownProbe(t *testing.T) {
+	c := New(zaptest.NewLogger(t), nil, nil)
+
+	me := entry("job", targetpb.Status_Active, "a")
+	me.Targetgroup.Probe.Type = "unknown"
+	c.Coordinate(me)
+
+	require.NotContains(t, c.tasks, "job")
+}
+
+func TestCoordinateHold(t *testing.T) {
+	c := New(zaptest.NewLogger(t), nil, nil)
+	c.Hold()
+
+	c.Coordinate(entry("held", targetpb.Status_Active, "h0", "h1"))
+	c.Coordinate(entry("gone", targetpb.Status_Active, "h2"))
+	c.Coordinate(entry("gone", targetpb.Status_Inactive))
+	require.Empty(t, c.tasks)
+
+	c.Release()
+	require.Len(t, c.tasks["held"], 2)
+	require.NotContains(t, c.tasks, "gone")
+}
+
+func TestTaskID(t *testing.T) {
+	probe := &targetpb.Probe{Type: ProbeTCP}
+
+	require.NotEqual(t, TaskID(probe, "a", nil), TaskID(probe, "b", nil))
+	require.NotEqual(t, TaskID(probe, "a", nil), TaskID(&targetpb.Probe{}, "a", nil))
+	require.Equal(t, TaskID(nil, "a", nil), TaskID(&targetpb.Probe{}, "a", nil))
+
+	// tuning the probe restarts the task
+	tuned := &targetpb.Probe{Type: ProbeTCP, Interval: 5 * time.Second}
+	require.NotEqual(t, TaskID(probe, "a", nil), TaskID(tuned, "a", nil))
+}
+
+func TestWithDefaults(t *testing.T) {
+	c := New(zaptest.NewLogger(t), nil, &targetpb.Histogram{
+		Buckets:            []float64{0.1, 1},
+		NativeBucketFactor: 1.1,
+	})
+
+	probe := c.withDefaults(nil)
+	require.Equal(t, []float64{0.1, 1}, probe.Histogram.Buckets)
+	require.Equal(t, 1.1, probe.Histogram.NativeBucketFactor)
+
+	// the job's own layout wins, and it's not modified
+	own := &targetpb.Probe{Histogram: &targetpb.Histogram{Buckets: []float64{0.5}}}
+	probe = c.withDefaults(own)
+	require.Equal(t, []float64{0.5}, probe.Histogram.Buckets)
+	require.Equal(t, 1.1, probe.Histogram.NativeBucketFactor)
+	require.Zero(t, own.Histogram.NativeBucketFactor)
+}
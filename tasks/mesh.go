@@ -0,0 +1,102 @@
+package tasks
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+)
+
+// meshJobPrefix prefixes the names of the local jobs created by Mesh,
+// one job per member, so members can be added and removed alone.
+const meshJobPrefix = "__mesh_"
+
+// ReservedJob returns true if the name is reserved for the jobs created
+// by gossiping itself, e.g. the mesh jobs, users cannot add or delete them.
+func ReservedJob(name string) bool {
+	return strings.HasPrefix(name, "__")
+}
+
+// isMeshJob returns true if the name is of a local mesh job, entries of
+// such names gossiped by others must not replace them.
+func isMeshJob(name string) bool {
+	return strings.HasPrefix(name, meshJobPrefix)
+}
+
+// Mesh makes the node probe every other member of the cluster, the
+// metrics carry the name of both the source and the destination node,
+// so the results of all nodes make up a latency matrix. The labels of the
+// destination node are attached with the prefix "dst_". The jobs are
+// coordinated locally, they are never gossiped.
+type Mesh struct {
+	collector *Collector
+	probe     *targetpb.Probe
+
+	mtx     sync.Mutex
+	members map[string]Member
+}
+
+// NewMesh creates a Mesh, probe configures how members are probed,
+// nil means icmp with the defaults.
+func NewMesh(collector *Collector, probe *targetpb.Probe) *Mesh {
+	return &Mesh{
+		collector: collector,
+		probe:     probe,
+		members:   make(map[string]Member),
+	}
+}
+
+// Update starts probing the new members and stops probing the members
+// which are gone, members should be the alive members of the cluster,
+// self is skipped.
+func (m *Mesh) Update(self string, members []Member) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	current := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		if member.Name == self {
+			continue
+		}
+
+		current[member.Name] = struct{}{}
+		if old, ok := m.members[member.Name]; ok && reflect.DeepEqual(old, member) {
+			continue
+		}
+
+		labels := map[string]string{
+			"src": self,
+			"dst": member.Name,
+		}
+		for k, v := range member.Labels {
+			labels["dst_"+k] = v
+		}
+
+		m.members[member.Name] = member
+		m.collector.apply(&targetpb.MeshEntry{
+			Name:    meshJobPrefix + member.Name,
+			Status:  targetpb.Status_Active,
+			Updated: time.Now(),
+			Targetgroup: &targetpb.Targetgroup{
+				Targets: []string{member.Addr},
+				Labels:  labels,
+				Probe:   m.probe,
+			},
+		})
+	}
+
+	for name := range m.members {
+		if _, found := current[name]; found {
+			continue
+		}
+
+		delete(m.members, name)
+		m.collector.apply(&targetpb.MeshEntry{
+			Name:    meshJobPrefix + name,
+			Status:  targetpb.Status_Inactive,
+			Updated: time.Now(),
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMesh(t *testing.T) {
+	c := New(zaptest.NewLogger(t), nil, nil)
+	m := NewMesh(c, &targetpb.Probe{Type: probeFake})
+
+	self := Member{Name: "self", Addr: "mesh-self"}
+	a := Member{Name: "a", Addr: "mesh-a"}
+	b := Member{Name: "b", Addr: "mesh-b"}
+
+	m.Update(self.Name, []Member{self, a, b})
+	require.Len(t, c.tasks, 2)
+	require.NotContains(t, fakeProbers, self.Addr)
+
+	pa := getFakeProber(t, a.Addr)
+	require.Equal(t, map[string]string{"src": "self", "dst": "a"}, pa.target.Labels)
+
+	// b left
+	pb := getFakeProber(t, b.Addr)
+	m.Update(self.Name, []Member{self, a})
+	waitStopped(t, pb)
+	require.Len(t, c.tasks, 1)
+	require.Same(t, pa, getFakeProber(t, a.Addr))
+
+	// a rejoined with another address
+	a.Addr = "mesh-a2"
+	m.Update(self.Name, []Member{self, a})
+	waitStopped(t, pa)
+	getFakeProber(t, a.Addr)
+
+	// gossiped jobs cannot delete mesh jobs
+	c.Coordinate(&targetpb.MeshEntry{Name: meshJobPrefix + a.Name, Status: targetpb.Status_Inactive})
+	require.Len(t, c.tasks, 1)
+	require.True(t, ReservedJob(meshJobPrefix+a.Name))
+	require.False(t, ReservedJob("job"))
+}
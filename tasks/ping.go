@@ -0,0 +1,488 @@
+package tasks
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	pingInterval = time.Second
+
+	// replies arrive later than this are dropped
+	pingTimeout = 10 * time.Second
+
+	// 8 bytes timestamp and 16 bytes tracker, it's the size go-ping used
+	pingPayloadSize = 24
+
+	// the payload carries the ICMP id in its first 2 bytes
+	minPingPayloadSize = 2
+
+	// the loss ratio is computed over the last 100 echo requests
+	pingWindow = 100
+
+	// hostnames are resolved again after this interval
+	pingResolveInterval = time.Minute
+	resolveTimeout      = 5 * time.Second
+
+	// failed resolutions and engine errors are retried after this
+	pingRetryInterval = 5 * time.Second
+
+	// the target is down once 3 echo requests in a row are lost
+	pingDownThreshold = 3
+)
+
+// pingProber sends ICMP echo requests to the target, the packets are
+// sent and received by the shared icmpEngine. Hostname targets are
+// resolved periodically, and the pingers follow the changes of the
+// addresses.
+type pingProber struct {
+	target          Target
+	hostname        bool
+	resolveAll      bool
+	resolveInterval time.Duration
+	interval        time.Duration
+	timeout         time.Duration
+	size            int
+	window          int
+	tos             uint8
+	dontFragment    bool
+	stopc           chan struct{}
+
+	// replaced by tests
+	lookup    func(ctx context.Context, host string) ([]net.IPAddr, error)
+	getEngine func(logger *zap.Logger, ip net.IP, tos uint8, dontFragment bool) (*icmpEngine, error)
+
+	mtx     sync.Mutex
+	targets map[string]*pingTarget
+
+	// metrics
+	info            *prometheus.Desc
+	resolveFailures prometheus.Counter
+}
+
+// pingTarget pings one address of the target
+type pingTarget struct {
+	ip     net.IP
+	pinger *pinger
+	engine *icmpEngine
+	stats  *pingStats
+
+	// metrics
+	recvPackets prometheus.Counter
+	sendPackets prometheus.Counter
+	rttDuration prometheus.Histogram
+	pingError   prometheus.Gauge
+	lossRatio   prometheus.GaugeFunc
+	jitter      prometheus.GaugeFunc
+	timeouts    prometheus.CounterFunc
+	duplicates  prometheus.CounterFunc
+	outOfOrder  prometheus.CounterFunc
+}
+
+func (t *pingTarget) Describe(descs chan<- *prometheus.Desc) {
+	descs <- t.recvPackets.Desc()
+	descs <- t.sendPackets.Desc()
+	descs <- t.rttDuration.Desc()
+	descs <- t.pingError.Desc()
+	descs <- t.lossRatio.Desc()
+	descs <- t.jitter.Desc()
+	descs <- t.timeouts.Desc()
+	descs <- t.duplicates.Desc()
+	descs <- t.outOfOrder.Desc()
+}
+
+func (t *pingTarget) Collect(metrics chan<- prometheus.Metric) {
+	t.sendPackets.Collect(metrics)
+	t.recvPackets.Collect(metrics)
+	t.rttDuration.Collect(metrics)
+	t.pingError.Collect(metrics)
+	t.lossRatio.Collect(metrics)
+	t.jitter.Collect(metrics)
+	t.timeouts.Collect(metrics)
+	t.duplicates.Collect(metrics)
+	t.outOfOrder.Collect(metrics)
+}
+
+func (p *pingProber) Describe(descs chan<- *prometheus.Desc) {
+	if p.hostname {
+		descs <- p.info
+		descs <- p.resolveFailures.Desc()
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, t := range p.targets {
+		t.Describe(descs)
+	}
+}
+
+func (p *pingProber) Collect(metrics chan<- prometheus.Metric) {
+	if p.hostname {
+		p.resolveFailures.Collect(metrics)
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, t := range p.targets {
+		if p.hostname {
+			metrics <- prometheus.MustNewConstMetric(p.info, prometheus.GaugeValue, 1, t.ip.String())
+		}
+
+		t.Collect(metrics)
+	}
+}
+
+func newPingProber(target Target) (Prober, error) {
+	size := pingPayloadSize
+	if n := target.Probe.GetPayloadSize(); n != 0 {
+		if n < minPingPayloadSize {
+			return nil, errors.Errorf("payload size must be at least %d bytes", minPingPayloadSize)
+		}
+
+		size = int(n)
+	}
+
+	window := pingWindow
+	if n := target.Probe.GetWindow(); n != 0 {
+		window = int(n)
+	}
+
+	resolveInterval := pingResolveInterval
+	if d := target.Probe.GetResolveInterval(); d > 0 {
+		resolveInterval = d
+	}
+
+	constLabels := target.ConstLabels()
+
+	info := prometheus.NewDesc(
+		"gossiping_ping_target_info",
+		"The addresses the hostname of the target resolved to",
+		[]string{"ip"},
+		constLabels)
+
+	resolveFailures := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "resolve_failures_total",
+		Help:        "The number of failed resolutions of the hostname",
+		ConstLabels: constLabels,
+	})
+
+	return &pingProber{
+		target:          target,
+		hostname:        net.ParseIP(target.Address) == nil,
+		resolveAll:      target.Probe.GetResolveAll(),
+		resolveInterval: resolveInterval,
+		interval:        target.Interval(pingInterval),
+		timeout:         target.Timeout(pingTimeout),
+		size:            size,
+		window:          window,
+		tos:             uint8(target.Probe.GetTos()),
+		dontFragment:    target.Probe.GetDontFragment(),
+		stopc:           make(chan struct{}),
+		lookup:          net.DefaultResolver.LookupIPAddr,
+		getEngine:       getEngine,
+		targets:         make(map[string]*pingTarget),
+		info:            info,
+		resolveFailures: resolveFailures,
+	}, nil
+}
+
+// newPingTarget creates the pinger and the metrics of the address, the
+// metrics carry an ip label if all addresses of the hostname are pinged.
+func (p *pingProber) newPingTarget(ip net.IP) *pingTarget {
+	constLabels := p.target.ConstLabels()
+	if p.hostname && p.resolveAll {
+		constLabels["ip"] = ip.String()
+	}
+
+	recvPackets := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "recv_packet_total",
+		Help:        "The Number of received packets",
+		ConstLabels: constLabels,
+	})
+
+	sendPackets := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "send_packet_total",
+		Help:        "The number of send packets",
+		ConstLabels: constLabels,
+	})
+
+	rttDuration := prometheus.NewHistogram(p.target.HistogramOpts(prometheus.HistogramOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "rtt_seconds",
+		Help:        "Round trip time of echo requests",
+		ConstLabels: constLabels,
+	}))
+
+	pingError := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "error",
+		ConstLabels: constLabels,
+	})
+
+	stats := newPingStats(p.timeout, p.window)
+
+	lossRatio := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "loss_ratio",
+		Help:        "Ratio of the lost echo requests in the window",
+		ConstLabels: constLabels,
+	}, stats.lossRatio)
+
+	jitter := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "jitter_seconds",
+		Help:        "Interarrival jitter of the replies, computed as RFC 3550 does",
+		ConstLabels: constLabels,
+	}, stats.jitterSeconds)
+
+	timeouts := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "timeouts_total",
+		Help:        "The number of echo requests not replied within the timeout",
+		ConstLabels: constLabels,
+	}, stats.timeoutCount)
+
+	duplicates := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "duplicates_total",
+		Help:        "The number of duplicated replies",
+		ConstLabels: constLabels,
+	}, stats.duplicateCount)
+
+	outOfOrder := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "ping",
+		Name:        "out_of_order_total",
+		Help:        "The number of replies arrived after the reply of a later request",
+		ConstLabels: constLabels,
+	}, stats.outOfOrderCount)
+
+	pinger := newPinger(ip, p.interval, p.size, stats)
+	pinger.onSend = func() {
+		pingError.Set(0)
+		sendPackets.Inc()
+	}
+	pinger.onRecv = func(rtt time.Duration) {
+		recvPackets.Inc()
+		rttDuration.Observe(rtt.Seconds())
+	}
+	pinger.onError = func(err error) {
+		pingError.Set(1)
+	}
+
+	// error until the first echo request is sent
+	pingError.Set(1)
+
+	return &pingTarget{
+		ip:          ip,
+		pinger:      pinger,
+		stats:       stats,
+		recvPackets: recvPackets,
+		sendPackets: sendPackets,
+		rttDuration: rttDuration,
+		pingError:   pingError,
+		lossRatio:   lossRatio,
+		jitter:      jitter,
+		timeouts:    timeouts,
+		duplicates:  duplicates,
+		outOfOrder:  outOfOrder,
+	}
+}
+
+// Health returns up if any address of the target is up
+func (p *pingProber) Health() Health {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	health := HealthUnknown
+	for _, t := range p.targets {
+		switch t.stats.health() {
+		case HealthUp:
+			return HealthUp
+		case HealthDown:
+			health = HealthDown
+		}
+	}
+
+	return health
+}
+
+func (p *pingProber) Start(logger *zap.Logger) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			logger.Error("task panicked",
+				zap.Stack("task"))
+		}
+	}()
+
+	defer p.removeAll()
+
+	for {
+		// IP targets wait for stop only once they are added
+		var wait <-chan time.Time
+		if !p.update(logger) {
+			wait = time.After(pingRetryInterval)
+		} else if p.hostname {
+			wait = time.After(p.resolveInterval)
+		}
+
+		select {
+		case <-p.stopc:
+			return
+		case <-wait:
+		}
+	}
+}
+
+// update resolves the target and reconciles the pingers with the
+// addresses, false is returned if it should be retried.
+func (p *pingProber) update(logger *zap.Logger) bool {
+	ips, err := p.resolve()
+	if err != nil {
+		// keep pinging the known addresses
+		p.resolveFailures.Inc()
+		logger.Warn("resolve target failed",
+			zap.String("addr", p.target.Address),
+			zap.Error(err))
+		return false
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	ok := true
+	current := make(map[string]struct{}, len(ips))
+	for _, ip := range ips {
+		key := ip.String()
+		current[key] = struct{}{}
+
+		t := p.targets[key]
+		if t == nil {
+			t = p.newPingTarget(ip)
+			p.targets[key] = t
+
+			if p.hostname {
+				logger.Info("target resolved",
+					zap.String("addr", p.target.Address),
+					zap.String("ip", key))
+			}
+		}
+
+		if t.engine != nil {
+			continue
+		}
+
+		engine, err := p.getEngine(logger, ip, p.tos, p.dontFragment)
+		if err == nil {
+			err = engine.add(t.pinger)
+		}
+
+		if err != nil {
+			ok = false
+			logger.Warn("ping error",
+				zap.String("addr", p.target.Address),
+				zap.Error(err))
+			continue
+		}
+
+		t.engine = engine
+	}
+
+	for key, t := range p.targets {
+		if _, found := current[key]; found {
+			continue
+		}
+
+		if t.engine != nil {
+			t.engine.remove(t.pinger)
+		}
+		delete(p.targets, key)
+	}
+
+	return ok
+}
+
+// resolve returns the addresses to ping. Unless all addresses are
+// wanted, the current address is kept as long as the hostname still
+// resolves to it, so round-robin DNS does not restart the pinger.
+func (p *pingProber) resolve() ([]net.IP, error) {
+	if !p.hostname {
+		return []net.IP{net.ParseIP(p.target.Address)}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	addrs, err := p.lookup(ctx, p.target.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addrs) == 0 {
+		return nil, errors.New("no address found")
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+
+	if p.resolveAll {
+		return ips, nil
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, ip := range ips {
+		if _, found := p.targets[ip.String()]; found {
+			return []net.IP{ip}, nil
+		}
+	}
+
+	// prefer IPv4 as net.ResolveIPAddr does
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return []net.IP{ip}, nil
+		}
+	}
+
+	return ips[:1], nil
+}
+
+func (p *pingProber) removeAll() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for key, t := range p.targets {
+		if t.engine != nil {
+			t.engine.remove(t.pinger)
+		}
+		delete(p.targets, key)
+	}
+}
+
+func (p *pingProber) Stop() {
+	close(p.stopc)
+}
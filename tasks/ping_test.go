@@ -0,0 +1,147 @@
+package tasks
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testPingProber returns a prober of the hostname, which resolves to the
+// addresses set to the returned pointer, and pings with engines not
+// started, so the pingers registered can be checked.
+func testPingProber(t *testing.T, resolveAll bool) (*pingProber, *[]string, map[bool]*icmpEngine) {
+	prober, err := newPingProber(Target{
+		Address: "example.com",
+		Probe:   &targetpb.Probe{Type: ProbeICMP, ResolveAll: resolveAll},
+	})
+	require.NoError(t, err)
+	p := prober.(*pingProber)
+
+	addrs := &[]string{}
+	p.lookup = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		require.Equal(t, "example.com", host)
+		if len(*addrs) == 0 {
+			return nil, errors.New("no such host")
+		}
+
+		var ips []net.IPAddr
+		for _, addr := range *addrs {
+			ips = append(ips, net.IPAddr{IP: net.ParseIP(addr)})
+		}
+		return ips, nil
+	}
+
+	engines := map[bool]*icmpEngine{
+		false: newEngine(zap.NewNop(), newFakeConn(), newIDSpace(), false, false),
+		true:  newEngine(zap.NewNop(), newFakeConn(), newIDSpace(), true, false),
+	}
+	p.getEngine = func(_ *zap.Logger, ip net.IP, _ uint8, _ bool) (*icmpEngine, error) {
+		return engines[ip.To4() == nil], nil
+	}
+
+	return p, addrs, engines
+}
+
+// registered returns the addresses of the pingers of the engine
+func registered(e *icmpEngine) []string {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	var addrs []string
+	for _, p := range e.pingers {
+		addrs = append(addrs, p.ip.String())
+	}
+	return addrs
+}
+
+func TestPingProberUpdate(t *testing.T) {
+	logger := zap.NewNop()
+	p, addrs, engines := testPingProber(t, false)
+
+	// IPv4 is preferred
+	*addrs = []string{"2001:db8::1", "192.0.2.1", "192.0.2.2"}
+	require.True(t, p.update(logger))
+	require.Len(t, p.targets, 1)
+	pinging := p.targets["192.0.2.1"]
+	require.NotNil(t, pinging)
+	require.Equal(t, []string{"192.0.2.1"}, registered(engines[false]))
+	require.Empty(t, registered(engines[true]))
+
+	// round-robin DNS does not restart the pinger
+	*addrs = []string{"192.0.2.2", "192.0.2.1"}
+	require.True(t, p.update(logger))
+	require.Len(t, p.targets, 1)
+	require.Same(t, pinging, p.targets["192.0.2.1"])
+
+	// known addresses are kept pinging if the resolution fails
+	*addrs = nil
+	require.False(t, p.update(logger))
+	require.Same(t, pinging, p.targets["192.0.2.1"])
+	require.Equal(t, float64(1), testutil.ToFloat64(p.resolveFailures))
+
+	// the address changed, the pinger is restarted
+	*addrs = []string{"192.0.2.3"}
+	require.True(t, p.update(logger))
+	require.Len(t, p.targets, 1)
+	require.NotNil(t, p.targets["192.0.2.3"])
+	require.Equal(t, []string{"192.0.2.3"}, registered(engines[false]))
+
+	p.removeAll()
+	require.Empty(t, p.targets)
+	require.Empty(t, registered(engines[false]))
+}
+
+func TestPingProberResolveAll(t *testing.T) {
+	logger := zap.NewNop()
+	p, addrs, engines := testPingProber(t, true)
+
+	// every A and AAAA address is pinged
+	*addrs = []string{"192.0.2.1", "192.0.2.2", "2001:db8::1"}
+	require.True(t, p.update(logger))
+	require.Len(t, p.targets, 3)
+	require.ElementsMatch(t, []string{"192.0.2.1", "192.0.2.2"}, registered(engines[false]))
+	require.Equal(t, []string{"2001:db8::1"}, registered(engines[true]))
+	kept := p.targets["192.0.2.2"]
+
+	// the addresses gone are removed, and the new ones are added
+	*addrs = []string{"192.0.2.2", "2001:db8::2"}
+	require.True(t, p.update(logger))
+	require.Len(t, p.targets, 2)
+	require.Same(t, kept, p.targets["192.0.2.2"])
+	require.Equal(t, []string{"192.0.2.2"}, registered(engines[false]))
+	require.Equal(t, []string{"2001:db8::2"}, registered(engines[true]))
+
+	// 1 info series per address
+	require.Equal(t, 2, testutil.CollectAndCount(p, "gossiping_ping_target_info"))
+}
+
+func TestPingProberEngineError(t *testing.T) {
+	logger := zap.NewNop()
+	p, addrs, engines := testPingProber(t, true)
+
+	failing := true
+	getEngine := p.getEngine
+	p.getEngine = func(logger *zap.Logger, ip net.IP, tos uint8, dontFragment bool) (*icmpEngine, error) {
+		if failing && ip.To4() == nil {
+			return nil, errors.New("listen ip6:ipv6-icmp: operation not permitted")
+		}
+		return getEngine(logger, ip, tos, dontFragment)
+	}
+
+	// the IPv4 address is pinged, and the IPv6 one is retried
+	*addrs = []string{"192.0.2.1", "2001:db8::1"}
+	require.False(t, p.update(logger))
+	require.Len(t, p.targets, 2)
+	require.Nil(t, p.targets["2001:db8::1"].engine)
+	require.Equal(t, []string{"192.0.2.1"}, registered(engines[false]))
+
+	failing = false
+	require.True(t, p.update(logger))
+	require.Equal(t, []string{"2001:db8::1"}, registered(engines[true]))
+}
@@ -0,0 +1,172 @@
+package tasks
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Builtin probe types
+const (
+	ProbeICMP = "icmp"
+	ProbeTCP  = "tcp"
+	ProbeHTTP = "http"
+	ProbeDNS  = "dns"
+)
+
+// Prober probes a single target and exposes the results as metrics.
+type Prober interface {
+	prometheus.Collector
+
+	// Start probes the target until Stop is called, it is called
+	// in a dedicated goroutine.
+	Start(logger *zap.Logger)
+
+	// Stop stops probing, it is called only once.
+	Stop()
+}
+
+// Health is the health of a target from the view of a prober
+type Health int32
+
+const (
+	HealthUnknown Health = iota
+	HealthUp
+	HealthDown
+)
+
+// HealthReporter is implemented by probers which can tell whether the
+// target is reachable, their results are gossiped to make up verdicts.
+type HealthReporter interface {
+	Health() Health
+}
+
+// healthState implements HealthReporter, probers embed it and set the
+// result of every probe.
+type healthState struct {
+	health atomic.Int32
+}
+
+func (h *healthState) setHealth(up bool) {
+	if up {
+		h.health.Store(int32(HealthUp))
+	} else {
+		h.health.Store(int32(HealthDown))
+	}
+}
+
+func (h *healthState) Health() Health {
+	return Health(h.health.Load())
+}
+
+// Target is a target of a job to be probed.
+type Target struct {
+	// Address of the target, its format depends on the probe type
+	Address string
+
+	Probe *targetpb.Probe
+
+	// Labels of the job and the external labels
+	Labels map[string]string
+}
+
+// ConstLabels returns the labels should be attached to the metrics
+// of the target.
+func (t Target) ConstLabels() prometheus.Labels {
+	constLabels := make(prometheus.Labels, len(t.Labels)+1)
+	for k, v := range t.Labels {
+		constLabels[k] = v
+	}
+	constLabels["target"] = t.Address
+
+	return constLabels
+}
+
+// Interval returns the interval between probes of the job, def is
+// returned if the job does not set it.
+func (t Target) Interval(def time.Duration) time.Duration {
+	if d := t.Probe.GetInterval(); d > 0 {
+		return d
+	}
+
+	return def
+}
+
+// Timeout returns the timeout of a single probe of the job, def is
+// returned if the job does not set it.
+func (t Target) Timeout(def time.Duration) time.Duration {
+	if d := t.Probe.GetTimeout(); d > 0 {
+		return d
+	}
+
+	return def
+}
+
+// defaultBuckets covers latencies from 0.5ms to 16s
+var defaultBuckets = prometheus.ExponentialBuckets(0.0005, 2, 16)
+
+// HistogramOpts fills opts with the histogram layout of the job, it should
+// be used by all latency histograms so they can be aggregated.
+func (t Target) HistogramOpts(opts prometheus.HistogramOpts) prometheus.HistogramOpts {
+	histogram := t.Probe.GetHistogram()
+
+	opts.Buckets = histogram.GetBuckets()
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = defaultBuckets
+	}
+
+	if factor := histogram.GetNativeBucketFactor(); factor > 1 {
+		opts.NativeHistogramBucketFactor = factor
+		// bound the memory of targets with wide latency ranges
+		opts.NativeHistogramMaxBucketNumber = 160
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+
+	return opts
+}
+
+// Factory creates a Prober for the target
+type Factory func(target Target) (Prober, error)
+
+var (
+	factoriesMtx sync.RWMutex
+	factories    = map[string]Factory{}
+)
+
+func init() {
+	Register(ProbeICMP, newPingProber)
+	Register(ProbeTCP, newTCPProber)
+	Register(ProbeHTTP, newHTTPProber)
+	Register(ProbeDNS, newDNSProber)
+}
+
+// Register makes a probe type available to jobs, it panics if
+// the type is registered twice.
+func Register(typ string, factory Factory) {
+	factoriesMtx.Lock()
+	defer factoriesMtx.Unlock()
+
+	if _, dup := factories[typ]; dup {
+		panic(fmt.Sprintf("probe type %q is registered twice", typ))
+	}
+
+	factories[typ] = factory
+}
+
+// Lookup returns the Factory of the probe type, an empty type means icmp.
+func Lookup(typ string) (Factory, bool) {
+	if typ == "" {
+		typ = ProbeICMP
+	}
+
+	factoriesMtx.RLock()
+	defer factoriesMtx.RUnlock()
+
+	factory, ok := factories[typ]
+	return factory, ok
+}
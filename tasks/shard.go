@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+)
+
+// Member is a node of the cluster
+type Member struct {
+	Name   string
+	Addr   string
+	Labels map[string]string
+}
+
+// matches reports whether the member has all labels of the selector
+func (m Member) matches(selector map[string]string) bool {
+	for k, v := range selector {
+		if m.Labels[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// candidates returns the names of the members the targets of the group
+// can be assigned to along with self, they match the node selector, and
+// have the same value of the spread label as self.
+func candidates(tg *targetpb.Targetgroup, self Member, members []Member) []string {
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		if !member.matches(tg.NodeSelector) {
+			continue
+		}
+
+		if tg.SpreadBy != "" && member.Labels[tg.SpreadBy] != self.Labels[tg.SpreadBy] {
+			continue
+		}
+
+		names = append(names, member.Name)
+	}
+
+	return names
+}
+
+// score returns the weight of the member for the key, the key is assigned
+// to the members with the highest weights, a.k.a. rendezvous hashing. So
+// only the keys of the member are moved when it joins or leaves.
+func score(key uint64, member string) uint64 {
+	h := hashAdd(hashNew(), member) ^ key
+
+	// the finalizer of splitmix64, fnv alone does not mix well
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+
+	return h
+}
+
+// assigned reports whether the key is assigned to self, which is one of
+// the replicas members with the highest weights. Every member is assigned
+// if replicas is zero or there are not more members than replicas.
+func assigned(key uint64, self string, members []string, replicas int) bool {
+	if replicas <= 0 || len(members) <= replicas {
+		return true
+	}
+
+	own := score(key, self)
+	higher := 0
+	for _, member := range members {
+		if member == self {
+			continue
+		}
+
+		s := score(key, member)
+		if s > own || (s == own && member < self) {
+			higher++
+			if higher >= replicas {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,72 @@
+package tasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func owners(key uint64, members []string, replicas int) []string {
+	var result []string
+	for _, member := range members {
+		if assigned(key, member, members, replicas) {
+			result = append(result, member)
+		}
+	}
+
+	return result
+}
+
+func TestAssigned(t *testing.T) {
+	members := []string{"a", "b", "c", "d", "e"}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		key := hashAdd(hashNew(), fmt.Sprintf("target-%d", i))
+		own := owners(key, members, 2)
+		require.Len(t, own, 2)
+
+		for _, member := range own {
+			counts[member]++
+		}
+
+		// only the keys of the leaving member move
+		left := owners(key, members[:4], 2)
+		if !contains(own, "e") {
+			require.Equal(t, own, left)
+		} else {
+			require.Subset(t, left, removeString(own, "e"))
+		}
+	}
+
+	// 4000 keys for every member
+	for member, count := range counts {
+		require.InDelta(t, 4000, count, 400, member)
+	}
+
+	// all members run the key
+	require.Equal(t, members, owners(1, members, 0))
+	require.Equal(t, members, owners(1, members, 5))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	var result []string
+	for _, item := range list {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package tasks
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// setDontFragment makes the kernel never fragment the packets sent by
+// conn, packets larger than the path MTU are rejected.
+func setDontFragment(conn net.PacketConn, v6 bool) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return errors.Errorf("unsupported connection %T", conn)
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		if v6 {
+			serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_DONTFRAG, 1)
+		} else {
+			serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_DO)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return serr
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package tasks
+
+import (
+	"net"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func setDontFragment(_ net.PacketConn, _ bool) error {
+	return errors.Errorf("don't fragment is not supported on %s", runtime.GOOS)
+}
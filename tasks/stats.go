@@ -0,0 +1,213 @@
+package tasks
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// echoRequest is an echo request waiting for its reply
+type echoRequest struct {
+	sent    time.Time
+	replied bool
+}
+
+// pingStats tracks the outcome of the echo requests of a target. An echo
+// request is lost if its reply does not arrive within the timeout, and
+// the loss ratio is computed over the last window requests that are
+// either replied or lost.
+type pingStats struct {
+	timeout time.Duration
+
+	mtx     sync.Mutex
+	seq     uint16
+	pending map[uint16]*echoRequest
+
+	// outcomes of the last requests, true means lost
+	outcomes []bool
+	next     int
+	filled   int
+	lost     int
+
+	// requests lost since the last reply
+	consecutiveLost int
+
+	// the highest sequence replied, replied is false if none is
+	lastSeq uint16
+	replied bool
+
+	// interarrival jitter, see RFC 3550 section 6.4.1
+	lastRTT time.Duration
+	jitter  float64
+
+	timeouts   uint64
+	duplicates uint64
+	outOfOrder uint64
+}
+
+func newPingStats(timeout time.Duration, window int) *pingStats {
+	return &pingStats{
+		timeout:  timeout,
+		pending:  make(map[uint16]*echoRequest),
+		outcomes: make([]bool, window),
+	}
+}
+
+// sent records an echo request, and returns the sequence to use. Requests
+// not replied within the timeout are counted as lost here, so they are
+// detected at most one interval late.
+func (s *pingStats) sent(now time.Time) uint16 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for seq, req := range s.pending {
+		if now.Sub(req.sent) <= s.timeout {
+			continue
+		}
+
+		// replied ones are kept until now to detect duplicates
+		if !req.replied {
+			s.timeouts++
+			s.record(true)
+		}
+		delete(s.pending, seq)
+	}
+
+	seq := s.seq
+	s.seq++
+	s.pending[seq] = &echoRequest{sent: now}
+
+	return seq
+}
+
+// received records the reply of seq, and returns its rtt. False is
+// returned if the reply should not be observed, e.g. it's unknown,
+// duplicated or arrives after the timeout.
+func (s *pingStats) received(seq uint16, now time.Time) (time.Duration, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	req, ok := s.pending[seq]
+	if !ok {
+		return 0, false
+	}
+
+	if req.replied {
+		s.duplicates++
+		return 0, false
+	}
+	req.replied = true
+
+	rtt := now.Sub(req.sent)
+	if rtt > s.timeout {
+		s.timeouts++
+		s.record(true)
+		return 0, false
+	}
+
+	s.record(false)
+
+	// sequences wrap around, so compare them by the distance
+	if s.replied && int16(seq-s.lastSeq) < 0 {
+		s.outOfOrder++
+	} else {
+		s.lastSeq = seq
+	}
+
+	if s.replied {
+		d := rtt - s.lastRTT
+		if d < 0 {
+			d = -d
+		}
+		s.jitter += (d.Seconds() - s.jitter) / 16
+	}
+	s.lastRTT = rtt
+	s.replied = true
+
+	return rtt, true
+}
+
+func (s *pingStats) record(lost bool) {
+	if lost {
+		s.consecutiveLost++
+	} else {
+		s.consecutiveLost = 0
+	}
+
+	if len(s.outcomes) == 0 {
+		return
+	}
+
+	if s.filled == len(s.outcomes) {
+		if s.outcomes[s.next] {
+			s.lost--
+		}
+	} else {
+		s.filled++
+	}
+
+	s.outcomes[s.next] = lost
+	if lost {
+		s.lost++
+	}
+	s.next = (s.next + 1) % len(s.outcomes)
+}
+
+// lossRatio returns the ratio of lost requests in the window, NaN is
+// returned if no request is replied or lost yet.
+func (s *pingStats) lossRatio() float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.filled == 0 {
+		return math.NaN()
+	}
+
+	return float64(s.lost) / float64(s.filled)
+}
+
+// health returns down once pingDownThreshold requests in a row are lost,
+// so a single lost request does not flip the health. It's up only once a
+// reply arrived, a target never replied is unknown till it's down.
+func (s *pingStats) health() Health {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	switch {
+	case s.consecutiveLost >= pingDownThreshold:
+		return HealthDown
+	case s.replied:
+		return HealthUp
+	default:
+		return HealthUnknown
+	}
+}
+
+// jitterSeconds returns the smoothed mean deviation of the rtt
+func (s *pingStats) jitterSeconds() float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.jitter
+}
+
+func (s *pingStats) timeoutCount() float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return float64(s.timeouts)
+}
+
+func (s *pingStats) duplicateCount() float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return float64(s.duplicates)
+}
+
+func (s *pingStats) outOfOrderCount() float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return float64(s.outOfOrder)
+}
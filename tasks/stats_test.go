@@ -0,0 +1,112 @@
+package tasks
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPingStatsLossRatio(t *testing.T) {
+	s := newPingStats(time.Second, 4)
+	now := time.Now()
+
+	require.True(t, math.IsNaN(s.lossRatio()))
+
+	seq := s.sent(now)
+	_, ok := s.received(seq, now.Add(10*time.Millisecond))
+	require.True(t, ok)
+
+	// the lost one is detected by the next request
+	s.sent(now.Add(time.Second))
+	seq = s.sent(now.Add(3 * time.Second))
+	require.Equal(t, 0.5, s.lossRatio())
+	require.Equal(t, float64(1), s.timeoutCount())
+
+	// the late reply is a timeout too
+	_, ok = s.received(seq, now.Add(5*time.Second))
+	require.False(t, ok)
+	require.InDelta(t, 2.0/3, s.lossRatio(), 1e-9)
+	require.Equal(t, float64(2), s.timeoutCount())
+
+	// the window slides
+	now = now.Add(5 * time.Second)
+	for i := 0; i < 2; i++ {
+		seq = s.sent(now)
+		_, ok = s.received(seq, now.Add(10*time.Millisecond))
+		require.True(t, ok)
+		now = now.Add(time.Second)
+	}
+	require.Equal(t, 0.5, s.lossRatio())
+}
+
+func TestPingStatsDuplicateAndOutOfOrder(t *testing.T) {
+	s := newPingStats(time.Second, 10)
+	s.seq = math.MaxUint16
+	now := time.Now()
+
+	first := s.sent(now)
+	second := s.sent(now)
+
+	// the sequence wraps around
+	_, ok := s.received(second, now.Add(time.Millisecond))
+	require.True(t, ok)
+	_, ok = s.received(first, now.Add(time.Millisecond))
+	require.True(t, ok)
+	require.Equal(t, float64(1), s.outOfOrderCount())
+
+	_, ok = s.received(second, now.Add(time.Millisecond))
+	require.False(t, ok)
+	require.Equal(t, float64(1), s.duplicateCount())
+
+	// replied requests are not lost
+	s.sent(now.Add(2 * time.Second))
+	require.Equal(t, float64(0), s.lossRatio())
+	require.Equal(t, float64(0), s.timeoutCount())
+}
+
+func TestPingStatsJitter(t *testing.T) {
+	s := newPingStats(time.Second, 10)
+	now := time.Now()
+
+	for _, rtt := range []time.Duration{10, 26, 10} {
+		seq := s.sent(now)
+		_, ok := s.received(seq, now.Add(rtt*time.Millisecond))
+		require.True(t, ok)
+	}
+
+	// J = 0.016/16, then J += (0.016 - J)/16
+	expected := 0.001
+	expected += (0.016 - expected) / 16
+	require.InDelta(t, expected, s.jitterSeconds(), 1e-9)
+}
+
+func TestPingStatsHealth(t *testing.T) {
+	s := newPingStats(time.Second, 10)
+	now := time.Now()
+	require.Equal(t, HealthUnknown, s.health())
+
+	// lost requests don't make a target never replied up
+	s.sent(now)
+	s.sent(now.Add(2 * time.Second))
+	require.Equal(t, float64(1), s.timeoutCount())
+	require.Equal(t, HealthUnknown, s.health())
+
+	for i := 1; i < pingDownThreshold; i++ {
+		now = now.Add(2 * time.Second)
+		s.sent(now.Add(2 * time.Second))
+	}
+	require.Equal(t, HealthDown, s.health())
+
+	seq := s.sent(now.Add(4 * time.Second))
+	_, ok := s.received(seq, now.Add(4*time.Second+time.Millisecond))
+	require.True(t, ok)
+	require.Equal(t, HealthUp, s.health())
+
+	// a single lost request doesn't flip it
+	now = now.Add(4 * time.Second)
+	s.sent(now.Add(time.Second))
+	s.sent(now.Add(3 * time.Second))
+	require.Equal(t, HealthUp, s.health())
+}
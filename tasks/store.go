@@ -6,21 +6,40 @@ import (
 	"io"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/f1shl3gs/gossiping/tasks/targetpb"
 	"github.com/matttproud/golang_protobuf_extensions/pbutil"
 )
 
 type Store struct {
-	mtx       sync.RWMutex
-	entries   map[string]*targetpb.MeshEntry
-	callbacks map[string]func(me *targetpb.MeshEntry)
+	mtx        sync.RWMutex
+	node       string
+	clock      *Clock
+	entries    map[string]*targetpb.MeshEntry
+	tombstones map[string]*tombstone
+	callbacks  map[string]func(me *targetpb.MeshEntry)
 }
 
-func NewStore() *Store {
+// NewStore creates a store of the entries, node is the name of the local
+// node, it breaks the ties of concurrent edits
+func NewStore(node string) *Store {
 	return &Store{
-		entries:   make(map[string]*targetpb.MeshEntry),
-		callbacks: make(map[string]func(me *targetpb.MeshEntry)),
+		node:       node,
+		clock:      NewClock(),
+		entries:    make(map[string]*targetpb.MeshEntry),
+		tombstones: make(map[string]*tombstone),
+		callbacks:  make(map[string]func(me *targetpb.MeshEntry)),
+	}
+}
+
+// Stamp versions the entry written by the local node, it must be called
+// before the entry is merged or broadcasted
+func (s *Store) Stamp(me *targetpb.MeshEntry) {
+	me.Updated = time.Now()
+	me.Version = targetpb.Version{
+		Hlc:  s.clock.Now(),
+		Node: s.node,
 	}
 }
 
@@ -36,13 +55,83 @@ func (s *Store) Snapshot(w io.Writer) error {
 	return json.NewEncoder(w).Encode(&mes)
 }
 
-// todo: may the enable compress for the state
 func (s *Store) MarshalBinary() ([]byte, error) {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 
-	buf := bytes.NewBuffer(nil)
+	entries := make([]*targetpb.MeshEntry, 0, len(s.entries))
 	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+
+	return marshalEntries(entries)
+}
+
+// Digest returns the versions of all entries, push/pull exchanges it
+// instead of the entries. The purged tombstones are in it too, so peers
+// know they are seen.
+func (s *Store) Digest() ([]byte, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	digest := targetpb.Digest{
+		Versions: make(map[string]targetpb.Version, len(s.entries)),
+		Node:     s.node,
+	}
+	for name, entry := range s.entries {
+		digest.Versions[name] = entry.Version
+	}
+	for name, ts := range s.tombstones {
+		if ts.purged() {
+			digest.Versions[name] = ts.entry.Version
+		}
+	}
+
+	return digest.Marshal()
+}
+
+// Delta returns the entries the digest lacks or has older versions of,
+// and the purged tombstones of the entries it has older versions of, the
+// tombstones it has are acknowledged.
+func (s *Store) Delta(b []byte) ([]byte, error) {
+	var digest targetpb.Digest
+	err := digest.Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var delta []*targetpb.MeshEntry
+	for name, entry := range s.entries {
+		v, ok := digest.Versions[name]
+		if !ok || compareVersions(entry.Version, v) > 0 {
+			delta = append(delta, entry)
+		}
+	}
+
+	for name, ts := range s.tombstones {
+		v, ok := digest.Versions[name]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case compareVersions(v, ts.entry.Version) >= 0:
+			ts.ack(digest.Node)
+		case ts.purged():
+			delta = append(delta, ts.entry)
+		}
+	}
+
+	return marshalEntries(delta)
+}
+
+// marshalEntries writes the entries delimited
+func marshalEntries(entries []*targetpb.MeshEntry) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	for _, entry := range entries {
 		_, err := pbutil.WriteDelimited(buf, entry)
 		if err != nil {
 			return nil, err
@@ -97,22 +186,57 @@ func (s *Store) Jobs() []string {
 	return names
 }
 
+// merge keeps the entry of the greater version, so all nodes converge to
+// the same entries no matter in which order they receive them
 func (s *Store) merge(me *targetpb.MeshEntry) bool {
+	migrate(me)
+	s.clock.Observe(me.Version.Hlc)
+
+	// the entry is deleted already
+	if ts, ok := s.tombstones[me.Name]; ok && ts.purged() {
+		if compareVersions(me.Version, ts.entry.Version) <= 0 {
+			return false
+		}
+
+		delete(s.tombstones, me.Name)
+	}
+
 	prev := s.entries[me.Name]
 	if prev == nil {
-		s.entries[me.Name] = me
+		s.put(me)
 		return true
 	}
 
-	if prev.Updated.After(me.Updated) {
+	switch compareVersions(me.Version, prev.Version) {
+	case -1:
 		return false
+	case 0:
+		// entries migrated from the same update time, the greater
+		// encoding wins, and identical ones are merged already
+		a, err := me.Marshal()
+		if err != nil {
+			return false
+		}
+		b, err := prev.Marshal()
+		if err != nil || bytes.Compare(a, b) <= 0 {
+			return false
+		}
 	}
 
-	s.entries[me.Name] = me
+	s.put(me)
 
 	return true
 }
 
+func (s *Store) put(me *targetpb.MeshEntry) {
+	s.entries[me.Name] = me
+	if me.Status == targetpb.Status_Inactive {
+		s.tombstones[me.Name] = newTombstone(me)
+	} else {
+		delete(s.tombstones, me.Name)
+	}
+}
+
 func (s *Store) AddCallback(name string, fn func(me *targetpb.MeshEntry)) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
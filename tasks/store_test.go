@@ -0,0 +1,173 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreDelta(t *testing.T) {
+	now := time.Now()
+	entry := func(name string, updated time.Time) *targetpb.MeshEntry {
+		return &targetpb.MeshEntry{Name: name, Status: targetpb.Status_Active, Updated: updated}
+	}
+
+	a := NewStore("a")
+	a.merge(entry("same", now))
+	a.merge(entry("newer", now.Add(time.Second)))
+	a.merge(entry("missing", now))
+	a.merge(entry("older", now))
+
+	b := NewStore("b")
+	b.merge(entry("same", now))
+	b.merge(entry("newer", now))
+	b.merge(entry("older", now.Add(time.Second)))
+	b.merge(entry("extra", now))
+
+	digest, err := b.Digest()
+	require.NoError(t, err)
+	delta, err := a.Delta(digest)
+	require.NoError(t, err)
+
+	c := NewStore("c")
+	require.NoError(t, c.Merge(delta))
+	require.Equal(t, []string{"missing", "newer"}, c.Jobs())
+
+	// the other way around, then both have everything
+	digest, err = a.Digest()
+	require.NoError(t, err)
+	delta, err = b.Delta(digest)
+	require.NoError(t, err)
+	require.NoError(t, a.Merge(delta))
+	require.Equal(t, []string{"extra", "missing", "newer", "older", "same"}, a.Jobs())
+	require.True(t, a.entries["older"].Updated.Equal(now.Add(time.Second)))
+
+	digest, err = a.Digest()
+	require.NoError(t, err)
+	delta, err = a.Delta(digest)
+	require.NoError(t, err)
+	require.Empty(t, delta)
+}
+
+func TestStoreConcurrentEdits(t *testing.T) {
+	now := time.Now()
+	a := NewStore("a")
+	a.clock.now = func() time.Time { return now }
+	// the wall clock of b is an hour ahead
+	b := NewStore("b")
+	b.clock.now = func() time.Time { return now.Add(time.Hour) }
+
+	write := func(s *Store, status targetpb.Status) *targetpb.MeshEntry {
+		me := &targetpb.MeshEntry{Name: "job", Status: status}
+		s.Stamp(me)
+		require.True(t, s.merge(me))
+		return me
+	}
+	exchange := func() {
+		for _, pair := range [][2]*Store{{a, b}, {b, a}} {
+			digest, err := pair[1].Digest()
+			require.NoError(t, err)
+			delta, err := pair[0].Delta(digest)
+			require.NoError(t, err)
+			require.NoError(t, pair[1].Merge(delta))
+		}
+	}
+
+	// b wins the concurrent edits since its clock is ahead
+	write(a, targetpb.Status_Active)
+	write(b, targetpb.Status_Inactive)
+	exchange()
+	require.Equal(t, targetpb.Status_Inactive, a.entries["job"].Status)
+	require.Equal(t, targetpb.Status_Inactive, b.entries["job"].Status)
+
+	// but an edit made after seeing it wins, though the clock of a is behind
+	write(a, targetpb.Status_Active)
+	exchange()
+	require.Equal(t, targetpb.Status_Active, a.entries["job"].Status)
+	require.Equal(t, targetpb.Status_Active, b.entries["job"].Status)
+
+	// ties are broken by node in any order
+	x := &targetpb.MeshEntry{Name: "tie", Status: targetpb.Status_Active, Version: targetpb.Version{Hlc: 1, Node: "x"}}
+	y := &targetpb.MeshEntry{Name: "tie", Status: targetpb.Status_Inactive, Version: targetpb.Version{Hlc: 1, Node: "y"}}
+	require.True(t, a.merge(x))
+	require.True(t, a.merge(y))
+	require.True(t, b.merge(y))
+	require.False(t, b.merge(x))
+	require.Equal(t, a.entries["tie"], b.entries["tie"])
+	require.Equal(t, "y", a.entries["tie"].Version.Node)
+}
+
+func TestStoreMigrate(t *testing.T) {
+	now := time.Now()
+	s := NewStore("a")
+
+	// written by nodes without clocks
+	legacy := &targetpb.MeshEntry{Name: "job", Status: targetpb.Status_Active, Updated: now}
+	require.True(t, s.merge(legacy))
+	require.Equal(t, physical(now), s.entries["job"].Version.Hlc)
+
+	// the local edits are newer than it
+	me := &targetpb.MeshEntry{Name: "job", Status: targetpb.Status_Inactive}
+	s.Stamp(me)
+	require.Greater(t, me.Version.Hlc, physical(now))
+	require.True(t, s.merge(me))
+
+	// legacy entries of the same update time converge by their encoding
+	x := &targetpb.MeshEntry{Name: "same", Status: targetpb.Status_Active, Updated: now}
+	y := &targetpb.MeshEntry{Name: "same", Status: targetpb.Status_Inactive, Updated: now}
+	require.True(t, s.merge(x))
+	require.True(t, s.merge(y))
+	require.False(t, s.merge(&targetpb.MeshEntry{Name: "same", Status: targetpb.Status_Active, Updated: now}))
+}
+
+func TestStoreTombstones(t *testing.T) {
+	a, b, c := NewStore("a"), NewStore("b"), NewStore("c")
+	exchange := func(x, y *Store) {
+		for _, pair := range [][2]*Store{{x, y}, {y, x}} {
+			digest, err := pair[1].Digest()
+			require.NoError(t, err)
+			delta, err := pair[0].Delta(digest)
+			require.NoError(t, err)
+			require.NoError(t, pair[1].Merge(delta))
+		}
+	}
+
+	active := &targetpb.MeshEntry{Name: "job", Status: targetpb.Status_Active}
+	a.Stamp(active)
+	require.True(t, a.merge(active))
+	exchange(a, b)
+	exchange(a, c)
+
+	deleted := &targetpb.MeshEntry{Name: "job", Status: targetpb.Status_Inactive}
+	a.Stamp(deleted)
+	require.True(t, a.merge(deleted))
+	require.Empty(t, a.GC([]string{"b"}, time.Hour))
+
+	// b acknowledges the tombstone by its digest of the next exchange
+	exchange(a, b)
+	require.Empty(t, a.GC([]string{"b"}, time.Hour))
+	exchange(a, b)
+	purged := a.GC([]string{"b"}, time.Hour)
+	require.Len(t, purged, 1)
+	require.Equal(t, "job", purged[0].Name)
+	require.Empty(t, a.Jobs())
+
+	// the purged tombstone is not sent back, and b purges it too
+	exchange(a, b)
+	require.Empty(t, a.Jobs())
+	require.Len(t, b.GC([]string{"a"}, time.Hour), 1)
+
+	// c missed the deletion, its stale entry is not resurrected, and it
+	// gets the tombstone
+	require.False(t, a.merge(active))
+	exchange(a, c)
+	require.Empty(t, a.Jobs())
+	require.Equal(t, targetpb.Status_Inactive, c.entries["job"].Status)
+
+	// tombstones not seen by all peers expire, and so do the purged ones
+	require.Len(t, c.GC([]string{"d"}, 0), 1)
+	require.Empty(t, c.GC(nil, 0))
+	require.Empty(t, c.tombstones)
+}
@@ -1,17 +1,248 @@
 package targetpb
 
 import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/discovery/targetgroup"
 )
 
-func FromProm(group *targetgroup.Group) *Targetgroup {
+const (
+	// ProbeLabel is the reserved label used to choose the probe type of a job.
+	ProbeLabel = "__probe__"
+
+	// HTTPBodyRegexLabel is the reserved label that holds the regular expression
+	// which the body of http responses should match.
+	HTTPBodyRegexLabel = "__http_body_regex__"
+
+	// DNSQueryNameLabel and DNSQueryTypeLabel are the reserved labels
+	// that hold the question of dns queries.
+	DNSQueryNameLabel = "__dns_query_name__"
+	DNSQueryTypeLabel = "__dns_query_type__"
+
+	// IntervalLabel and TimeoutLabel are the reserved labels that hold the
+	// interval between probes and the timeout of a single probe, e.g. 5s.
+	IntervalLabel = "__interval__"
+	TimeoutLabel  = "__timeout__"
+
+	// PayloadSizeLabel, TOSLabel and DontFragmentLabel are the reserved labels
+	// that tune the ICMP echo requests.
+	PayloadSizeLabel  = "__payload_size__"
+	TOSLabel          = "__tos__"
+	DontFragmentLabel = "__dont_fragment__"
+
+	// WindowLabel is the reserved label that holds the number of the latest
+	// ICMP echo requests the loss ratio is computed over.
+	WindowLabel = "__window__"
+
+	// BucketsLabel and NativeBucketFactorLabel are the reserved labels that
+	// hold the layout of the latency histograms, the buckets are upper
+	// bounds in seconds separated by comma, e.g. 0.001,0.01,0.1
+	BucketsLabel            = "__buckets__"
+	NativeBucketFactorLabel = "__native_bucket_factor__"
+
+	// ResolveIntervalLabel and ResolveAllLabel are the reserved labels that
+	// control how hostname targets of ICMP probes are resolved.
+	ResolveIntervalLabel = "__resolve_interval__"
+	ResolveAllLabel      = "__resolve_all__"
+
+	// ReplicasLabel is the reserved label that holds the number of nodes
+	// each target of the job is probed by.
+	ReplicasLabel = "__replicas__"
+
+	// NodeSelectorLabel is the reserved label that holds the labels the nodes
+	// must have to probe the job, e.g. region=x,zone=a, and SpreadByLabel
+	// holds the node label the replicas are spread by, e.g. zone.
+	NodeSelectorLabel = "__node_selector__"
+	SpreadByLabel     = "__spread_by__"
+)
+
+// MinInterval bounds the intervals of probes and resolutions, so a job can
+// not make every node flood its targets
+const MinInterval = 100 * time.Millisecond
+
+// maxWindow limits the memory a target takes
+const maxWindow = 1 << 16
+
+// maxPayloadSize is the max payload of an ICMP echo request in IPv4, and
+// the payload carries the ICMP id in its first 2 bytes
+const (
+	maxPayloadSize = 65507
+	minPayloadSize = 2
+)
+
+// promLabels are the reserved labels of Prometheus, target groups shared
+// with it might carry them
+var promLabels = map[string]struct{}{
+	model.AddressLabel:        {},
+	model.SchemeLabel:         {},
+	model.MetricsPathLabel:    {},
+	model.ScrapeIntervalLabel: {},
+	model.ScrapeTimeoutLabel:  {},
+}
+
+// FromProm converts a Prometheus target group to a Targetgroup. Reserved
+// labels, which are prefixed with "__", configure the probe and are not
+// attached to the metrics, unknown ones in the form of __x__ are rejected,
+// so a typo is not ignored silently.
+func FromProm(group *targetgroup.Group) (*Targetgroup, error) {
 	tg := &Targetgroup{
 		Labels: make(map[string]string, len(group.Labels)),
+		Probe:  &Probe{},
 	}
 
 	for k, v := range group.Labels {
-		tg.Labels[string(k)] = string(v)
+		name := string(k)
+		if !strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			tg.Labels[name] = string(v)
+			continue
+		}
+
+		switch name {
+		case ProbeLabel:
+			tg.Probe.Type = strings.ToLower(string(v))
+		case HTTPBodyRegexLabel:
+			_, err := regexp.Compile(string(v))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s", name)
+			}
+
+			tg.Probe.Http = &HTTPProbe{BodyRegex: string(v)}
+		case DNSQueryNameLabel:
+			if tg.Probe.Dns == nil {
+				tg.Probe.Dns = &DNSProbe{}
+			}
+
+			tg.Probe.Dns.QueryName = string(v)
+		case DNSQueryTypeLabel:
+			qtype := strings.ToUpper(string(v))
+			if _, ok := dns.StringToType[qtype]; !ok {
+				return nil, errors.Errorf("invalid %s %q", name, v)
+			}
+
+			if tg.Probe.Dns == nil {
+				tg.Probe.Dns = &DNSProbe{}
+			}
+
+			tg.Probe.Dns.QueryType = qtype
+		case IntervalLabel:
+			d, err := parseDuration(name, string(v))
+			if err != nil {
+				return nil, err
+			}
+
+			tg.Probe.Interval = d
+		case TimeoutLabel:
+			d, err := parseDuration(name, string(v))
+			if err != nil {
+				return nil, err
+			}
+
+			tg.Probe.Timeout = d
+		case PayloadSizeLabel:
+			size, err := parseUint(name, string(v), maxPayloadSize)
+			if err != nil {
+				return nil, err
+			}
+
+			tg.Probe.PayloadSize = size
+		case TOSLabel:
+			tos, err := parseUint(name, string(v), math.MaxUint8)
+			if err != nil {
+				return nil, err
+			}
+
+			tg.Probe.Tos = tos
+		case DontFragmentLabel:
+			df, err := strconv.ParseBool(string(v))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s", name)
+			}
+
+			tg.Probe.DontFragment = df
+		case WindowLabel:
+			window, err := parseUint(name, string(v), maxWindow)
+			if err != nil {
+				return nil, err
+			}
+
+			tg.Probe.Window = window
+		case BucketsLabel:
+			buckets, err := parseBuckets(name, string(v))
+			if err != nil {
+				return nil, err
+			}
+
+			if tg.Probe.Histogram == nil {
+				tg.Probe.Histogram = &Histogram{}
+			}
+
+			tg.Probe.Histogram.Buckets = buckets
+		case NativeBucketFactorLabel:
+			factor, err := strconv.ParseFloat(string(v), 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s", name)
+			}
+
+			if factor <= 1 {
+				return nil, errors.Errorf("%s must be greater than 1", name)
+			}
+
+			if tg.Probe.Histogram == nil {
+				tg.Probe.Histogram = &Histogram{}
+			}
+
+			tg.Probe.Histogram.NativeBucketFactor = factor
+		case ResolveIntervalLabel:
+			d, err := parseDuration(name, string(v))
+			if err != nil {
+				return nil, err
+			}
+
+			tg.Probe.ResolveInterval = d
+		case ResolveAllLabel:
+			all, err := strconv.ParseBool(string(v))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s", name)
+			}
+
+			tg.Probe.ResolveAll = all
+		case ReplicasLabel:
+			replicas, err := parseUint(name, string(v), math.MaxUint16)
+			if err != nil {
+				return nil, err
+			}
+
+			tg.Replicas = replicas
+		case NodeSelectorLabel:
+			selector, err := parseSelector(name, string(v))
+			if err != nil {
+				return nil, err
+			}
+
+			tg.NodeSelector = selector
+		case SpreadByLabel:
+			if !model.LabelName(v).IsValid() {
+				return nil, errors.Errorf("invalid %s %q", name, v)
+			}
+
+			tg.SpreadBy = string(v)
+		default:
+			if _, ok := promLabels[name]; !ok && strings.HasSuffix(name, "__") {
+				return nil, errors.Errorf("unknown reserved label %s", name)
+			}
+		}
+	}
+
+	err := tg.Validate()
+	if err != nil {
+		return nil, err
 	}
 
 	tg.Targets = make([]string, 0, len(group.Targets))
@@ -20,5 +251,95 @@ func FromProm(group *targetgroup.Group) *Targetgroup {
 		tg.Targets = append(tg.Targets, addr)
 	}
 
-	return tg
+	return tg, nil
+}
+
+// Validate checks the settings which depend on each other or have lower
+// bounds, jobs gossiped by other nodes are checked too, since they might
+// be of older versions.
+func (tg *Targetgroup) Validate() error {
+	probe := tg.GetProbe()
+	for name, d := range map[string]time.Duration{
+		IntervalLabel:        probe.GetInterval(),
+		ResolveIntervalLabel: probe.GetResolveInterval(),
+	} {
+		if d != 0 && d < MinInterval {
+			return errors.Errorf("%s must not be less than %s", name, MinInterval)
+		}
+	}
+
+	if n := probe.GetPayloadSize(); n != 0 && n < minPayloadSize {
+		return errors.Errorf("%s must be at least %d", PayloadSizeLabel, minPayloadSize)
+	}
+
+	if probe.GetDns() != nil && probe.GetDns().GetQueryName() == "" {
+		return errors.Errorf("%s is required", DNSQueryNameLabel)
+	}
+
+	return nil
+}
+
+// parseDuration parses durations in the Prometheus format, e.g. 500ms or 1m
+func parseDuration(name, value string) (time.Duration, error) {
+	d, err := model.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s", name)
+	}
+
+	if d <= 0 {
+		return 0, errors.Errorf("%s must be positive", name)
+	}
+
+	return time.Duration(d), nil
+}
+
+// parseUint parses value which accepts decimal, hex(0x) and octal(0) format
+// so TOS can be written as 0xb8
+func parseUint(name, value string, max uint64) (uint32, error) {
+	n, err := strconv.ParseUint(value, 0, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s", name)
+	}
+
+	if n > max {
+		return 0, errors.Errorf("%s must not be greater than %d", name, max)
+	}
+
+	return uint32(n), nil
+}
+
+// parseBuckets parses upper bounds separated by comma, they must be
+// in increasing order
+func parseBuckets(name, value string) ([]float64, error) {
+	fields := strings.Split(value, ",")
+	buckets := make([]float64, 0, len(fields))
+	for _, field := range fields {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", name)
+		}
+
+		if len(buckets) > 0 && bound <= buckets[len(buckets)-1] {
+			return nil, errors.Errorf("%s must be in increasing order", name)
+		}
+
+		buckets = append(buckets, bound)
+	}
+
+	return buckets, nil
+}
+
+// parseSelector parses labels in the format of name=value separated by comma
+func parseSelector(name, value string) (map[string]string, error) {
+	selector := make(map[string]string)
+	for _, field := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok || !model.LabelName(k).IsValid() {
+			return nil, errors.Errorf("invalid %s %q", name, value)
+		}
+
+		selector[k] = v
+	}
+
+	return selector, nil
 }
@@ -0,0 +1,58 @@
+package targetpb
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromPromReservedLabels(t *testing.T) {
+	for name, tc := range map[string]struct {
+		labels model.LabelSet
+		err    string
+	}{
+		"valid": {
+			labels: model.LabelSet{
+				IntervalLabel:      "100ms",
+				PayloadSizeLabel:   "2",
+				model.AddressLabel: "192.0.2.1",
+				"__meta_zone":      "a",
+			},
+		},
+		"unknown": {
+			labels: model.LabelSet{"__dont_fragmet__": "true"},
+			err:    "unknown reserved label __dont_fragmet__",
+		},
+		"interval too short": {
+			labels: model.LabelSet{IntervalLabel: "1ms"},
+			err:    "__interval__ must not be less than 100ms",
+		},
+		"resolve interval too short": {
+			labels: model.LabelSet{ResolveIntervalLabel: "10ms"},
+			err:    "__resolve_interval__ must not be less than 100ms",
+		},
+		"payload too small": {
+			labels: model.LabelSet{PayloadSizeLabel: "1"},
+			err:    "__payload_size__ must be at least 2",
+		},
+		"dns query name missing": {
+			labels: model.LabelSet{ProbeLabel: "dns", DNSQueryTypeLabel: "A"},
+			err:    "__dns_query_name__ is required",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := FromProm(&targetgroup.Group{
+				Targets: []model.LabelSet{{model.AddressLabel: "192.0.2.1"}},
+				Labels:  tc.labels,
+			})
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -4,6 +4,7 @@
 package targetpb
 
 import (
+	encoding_binary "encoding/binary"
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
@@ -55,16 +56,323 @@ func (Status) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_468528a86129e532, []int{0}
 }
 
+type HTTPProbe struct {
+	// regular expression the response body is matched against, empty
+	// disables the matching
+	BodyRegex string `protobuf:"bytes,1,opt,name=body_regex,json=bodyRegex,proto3" json:"body_regex,omitempty"`
+}
+
+func (m *HTTPProbe) Reset()         { *m = HTTPProbe{} }
+func (m *HTTPProbe) String() string { return proto.CompactTextString(m) }
+func (*HTTPProbe) ProtoMessage()    {}
+func (*HTTPProbe) Descriptor() ([]byte, []int) {
+	return fileDescriptor_468528a86129e532, []int{0}
+}
+func (m *HTTPProbe) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HTTPProbe) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HTTPProbe.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *HTTPProbe) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HTTPProbe.Merge(m, src)
+}
+func (m *HTTPProbe) XXX_Size() int {
+	return m.Size()
+}
+func (m *HTTPProbe) XXX_DiscardUnknown() {
+	xxx_messageInfo_HTTPProbe.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HTTPProbe proto.InternalMessageInfo
+
+func (m *HTTPProbe) GetBodyRegex() string {
+	if m != nil {
+		return m.BodyRegex
+	}
+	return ""
+}
+
+type DNSProbe struct {
+	// the name to resolve
+	QueryName string `protobuf:"bytes,1,opt,name=query_name,json=queryName,proto3" json:"query_name,omitempty"`
+	// record type of the query, e.g. A, AAAA or MX, empty means A
+	QueryType string `protobuf:"bytes,2,opt,name=query_type,json=queryType,proto3" json:"query_type,omitempty"`
+}
+
+func (m *DNSProbe) Reset()         { *m = DNSProbe{} }
+func (m *DNSProbe) String() string { return proto.CompactTextString(m) }
+func (*DNSProbe) ProtoMessage()    {}
+func (*DNSProbe) Descriptor() ([]byte, []int) {
+	return fileDescriptor_468528a86129e532, []int{1}
+}
+func (m *DNSProbe) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DNSProbe) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DNSProbe.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DNSProbe) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DNSProbe.Merge(m, src)
+}
+func (m *DNSProbe) XXX_Size() int {
+	return m.Size()
+}
+func (m *DNSProbe) XXX_DiscardUnknown() {
+	xxx_messageInfo_DNSProbe.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DNSProbe proto.InternalMessageInfo
+
+func (m *DNSProbe) GetQueryName() string {
+	if m != nil {
+		return m.QueryName
+	}
+	return ""
+}
+
+func (m *DNSProbe) GetQueryType() string {
+	if m != nil {
+		return m.QueryType
+	}
+	return ""
+}
+
+type Histogram struct {
+	// upper bounds of the buckets in seconds, empty means the default
+	Buckets []float64 `protobuf:"fixed64,1,rep,packed,name=buckets,proto3" json:"buckets,omitempty"`
+	// growth factor of the buckets of native histograms, native histograms
+	// are exposed along with the classic ones if it's greater than 1
+	NativeBucketFactor float64 `protobuf:"fixed64,2,opt,name=native_bucket_factor,json=nativeBucketFactor,proto3" json:"native_bucket_factor,omitempty"`
+}
+
+func (m *Histogram) Reset()         { *m = Histogram{} }
+func (m *Histogram) String() string { return proto.CompactTextString(m) }
+func (*Histogram) ProtoMessage()    {}
+func (*Histogram) Descriptor() ([]byte, []int) {
+	return fileDescriptor_468528a86129e532, []int{2}
+}
+func (m *Histogram) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Histogram) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Histogram.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Histogram) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Histogram.Merge(m, src)
+}
+func (m *Histogram) XXX_Size() int {
+	return m.Size()
+}
+func (m *Histogram) XXX_DiscardUnknown() {
+	xxx_messageInfo_Histogram.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Histogram proto.InternalMessageInfo
+
+func (m *Histogram) GetBuckets() []float64 {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+func (m *Histogram) GetNativeBucketFactor() float64 {
+	if m != nil {
+		return m.NativeBucketFactor
+	}
+	return 0
+}
+
+type Probe struct {
+	// type of the probe, e.g. icmp, tcp, http or dns, empty means icmp
+	Type string     `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Http *HTTPProbe `protobuf:"bytes,2,opt,name=http,proto3" json:"http,omitempty"`
+	Dns  *DNSProbe  `protobuf:"bytes,3,opt,name=dns,proto3" json:"dns,omitempty"`
+	// interval between two probes, zero means the default of the type
+	Interval time.Duration `protobuf:"bytes,4,opt,name=interval,proto3,stdduration" json:"interval"`
+	// timeout of a single probe, zero means the default of the type
+	Timeout time.Duration `protobuf:"bytes,5,opt,name=timeout,proto3,stdduration" json:"timeout"`
+	// the following are used by icmp only
+	// payload size of echo requests in bytes, zero means the default
+	PayloadSize uint32 `protobuf:"varint,6,opt,name=payload_size,json=payloadSize,proto3" json:"payload_size,omitempty"`
+	// type of service(IPv4) or traffic class(IPv6) of echo requests
+	Tos uint32 `protobuf:"varint,7,opt,name=tos,proto3" json:"tos,omitempty"`
+	// set the don't fragment bit(IPv4) or disable fragmentation(IPv6)
+	DontFragment bool `protobuf:"varint,8,opt,name=dont_fragment,json=dontFragment,proto3" json:"dont_fragment,omitempty"`
+	// number of the latest echo requests the loss ratio is computed over,
+	// zero means the default
+	Window uint32 `protobuf:"varint,9,opt,name=window,proto3" json:"window,omitempty"`
+	// layout of the latency histograms
+	Histogram *Histogram `protobuf:"bytes,10,opt,name=histogram,proto3" json:"histogram,omitempty"`
+	// interval of resolving hostname targets, zero means the default
+	ResolveInterval time.Duration `protobuf:"bytes,11,opt,name=resolve_interval,json=resolveInterval,proto3,stdduration" json:"resolve_interval"`
+	// ping every address of hostname targets instead of one of them
+	ResolveAll bool `protobuf:"varint,12,opt,name=resolve_all,json=resolveAll,proto3" json:"resolve_all,omitempty"`
+}
+
+func (m *Probe) Reset()         { *m = Probe{} }
+func (m *Probe) String() string { return proto.CompactTextString(m) }
+func (*Probe) ProtoMessage()    {}
+func (*Probe) Descriptor() ([]byte, []int) {
+	return fileDescriptor_468528a86129e532, []int{3}
+}
+func (m *Probe) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Probe) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Probe.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Probe) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Probe.Merge(m, src)
+}
+func (m *Probe) XXX_Size() int {
+	return m.Size()
+}
+func (m *Probe) XXX_DiscardUnknown() {
+	xxx_messageInfo_Probe.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Probe proto.InternalMessageInfo
+
+func (m *Probe) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Probe) GetHttp() *HTTPProbe {
+	if m != nil {
+		return m.Http
+	}
+	return nil
+}
+
+func (m *Probe) GetDns() *DNSProbe {
+	if m != nil {
+		return m.Dns
+	}
+	return nil
+}
+
+func (m *Probe) GetInterval() time.Duration {
+	if m != nil {
+		return m.Interval
+	}
+	return 0
+}
+
+func (m *Probe) GetTimeout() time.Duration {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *Probe) GetPayloadSize() uint32 {
+	if m != nil {
+		return m.PayloadSize
+	}
+	return 0
+}
+
+func (m *Probe) GetTos() uint32 {
+	if m != nil {
+		return m.Tos
+	}
+	return 0
+}
+
+func (m *Probe) GetDontFragment() bool {
+	if m != nil {
+		return m.DontFragment
+	}
+	return false
+}
+
+func (m *Probe) GetWindow() uint32 {
+	if m != nil {
+		return m.Window
+	}
+	return 0
+}
+
+func (m *Probe) GetHistogram() *Histogram {
+	if m != nil {
+		return m.Histogram
+	}
+	return nil
+}
+
+func (m *Probe) GetResolveInterval() time.Duration {
+	if m != nil {
+		return m.ResolveInterval
+	}
+	return 0
+}
+
+func (m *Probe) GetResolveAll() bool {
+	if m != nil {
+		return m.ResolveAll
+	}
+	return false
+}
+
 type Targetgroup struct {
 	Targets []string          `protobuf:"bytes,1,rep,name=targets,proto3" json:"targets,omitempty"`
 	Labels  map[string]string `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Probe   *Probe            `protobuf:"bytes,3,opt,name=probe,proto3" json:"probe,omitempty"`
+	// number of nodes each target is probed by, zero means all nodes
+	Replicas uint32 `protobuf:"varint,4,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	// labels the nodes must have to probe the targets
+	NodeSelector map[string]string `protobuf:"bytes,5,rep,name=node_selector,json=nodeSelector,proto3" json:"node_selector,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// label of nodes, the targets are assigned to replicas nodes of every
+	// value of it, e.g. one node per zone
+	SpreadBy string `protobuf:"bytes,6,opt,name=spread_by,json=spreadBy,proto3" json:"spread_by,omitempty"`
 }
 
 func (m *Targetgroup) Reset()         { *m = Targetgroup{} }
 func (m *Targetgroup) String() string { return proto.CompactTextString(m) }
 func (*Targetgroup) ProtoMessage()    {}
 func (*Targetgroup) Descriptor() ([]byte, []int) {
-	return fileDescriptor_468528a86129e532, []int{0}
+	return fileDescriptor_468528a86129e532, []int{4}
 }
 func (m *Targetgroup) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -107,18 +415,105 @@ func (m *Targetgroup) GetLabels() map[string]string {
 	return nil
 }
 
+func (m *Targetgroup) GetProbe() *Probe {
+	if m != nil {
+		return m.Probe
+	}
+	return nil
+}
+
+func (m *Targetgroup) GetReplicas() uint32 {
+	if m != nil {
+		return m.Replicas
+	}
+	return 0
+}
+
+func (m *Targetgroup) GetNodeSelector() map[string]string {
+	if m != nil {
+		return m.NodeSelector
+	}
+	return nil
+}
+
+func (m *Targetgroup) GetSpreadBy() string {
+	if m != nil {
+		return m.SpreadBy
+	}
+	return ""
+}
+
+// Version orders the edits of mesh entries, the greater hlc wins, and the
+// greater node wins if they are equal
+type Version struct {
+	// hybrid logical clock, milliseconds of the wall clock shifted left by
+	// 16 bits plus a logical counter
+	Hlc int64 `protobuf:"varint,1,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	// name of the node the entry is written by
+	Node string `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+}
+
+func (m *Version) Reset()         { *m = Version{} }
+func (m *Version) String() string { return proto.CompactTextString(m) }
+func (*Version) ProtoMessage()    {}
+func (*Version) Descriptor() ([]byte, []int) {
+	return fileDescriptor_468528a86129e532, []int{5}
+}
+func (m *Version) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Version) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Version.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Version) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Version.Merge(m, src)
+}
+func (m *Version) XXX_Size() int {
+	return m.Size()
+}
+func (m *Version) XXX_DiscardUnknown() {
+	xxx_messageInfo_Version.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Version proto.InternalMessageInfo
+
+func (m *Version) GetHlc() int64 {
+	if m != nil {
+		return m.Hlc
+	}
+	return 0
+}
+
+func (m *Version) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
 type MeshEntry struct {
-	Name        string       `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Status      Status       `protobuf:"varint,2,opt,name=status,proto3,enum=targetpb.Status" json:"status,omitempty"`
+	Name   string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Status Status `protobuf:"varint,2,opt,name=status,proto3,enum=targetpb.Status" json:"status,omitempty"`
+	// wall clock of the writer, entries without version are versioned by it
 	Updated     time.Time    `protobuf:"bytes,3,opt,name=updated,proto3,stdtime" json:"updated"`
 	Targetgroup *Targetgroup `protobuf:"bytes,4,opt,name=targetgroup,proto3" json:"targetgroup,omitempty"`
+	Version     Version      `protobuf:"bytes,5,opt,name=version,proto3" json:"version"`
 }
 
 func (m *MeshEntry) Reset()         { *m = MeshEntry{} }
 func (m *MeshEntry) String() string { return proto.CompactTextString(m) }
 func (*MeshEntry) ProtoMessage()    {}
 func (*MeshEntry) Descriptor() ([]byte, []int) {
-	return fileDescriptor_468528a86129e532, []int{1}
+	return fileDescriptor_468528a86129e532, []int{6}
 }
 func (m *MeshEntry) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -175,95 +570,378 @@ func (m *MeshEntry) GetTargetgroup() *Targetgroup {
 	return nil
 }
 
-func init() {
-	proto.RegisterEnum("targetpb.Status", Status_name, Status_value)
-	proto.RegisterType((*Targetgroup)(nil), "targetpb.Targetgroup")
-	proto.RegisterMapType((map[string]string)(nil), "targetpb.Targetgroup.LabelsEntry")
-	proto.RegisterType((*MeshEntry)(nil), "targetpb.MeshEntry")
+func (m *MeshEntry) GetVersion() Version {
+	if m != nil {
+		return m.Version
+	}
+	return Version{}
 }
 
-func init() { proto.RegisterFile("target.proto", fileDescriptor_468528a86129e532) }
+// Digest is the versions of the mesh entries of a node, keyed by name
+type Digest struct {
+	Versions map[string]Version `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// name of the node of the digest, it acknowledges the tombstones in it
+	Node string `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+}
 
-var fileDescriptor_468528a86129e532 = []byte{
-	// 369 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x50, 0xb1, 0x8e, 0xda, 0x40,
-	0x14, 0xf4, 0xda, 0xc4, 0xc0, 0x33, 0x8a, 0xac, 0x55, 0x22, 0xad, 0x5c, 0x18, 0x87, 0xca, 0x8a,
-	0x94, 0xb5, 0x44, 0x8a, 0x84, 0x14, 0x91, 0x82, 0x94, 0x22, 0x52, 0xd2, 0x38, 0xe4, 0x03, 0xd6,
-	0xb0, 0x31, 0x08, 0xdb, 0x6b, 0xd9, 0x6b, 0x22, 0xfe, 0x82, 0x1f, 0xc8, 0xcf, 0x5c, 0x45, 0x49,
-	0x79, 0xd5, 0xdd, 0x09, 0x7e, 0xe4, 0xc4, 0x2e, 0x16, 0x14, 0xd7, 0xbd, 0x19, 0xcf, 0x8c, 0x67,
-	0x07, 0x06, 0x92, 0x55, 0x29, 0x97, 0xb4, 0xac, 0x84, 0x14, 0xb8, 0xa7, 0x51, 0x99, 0x78, 0xc3,
-	0x54, 0x88, 0x34, 0xe3, 0x91, 0xe2, 0x93, 0xe6, 0x6f, 0x24, 0x57, 0x39, 0xaf, 0x25, 0xcb, 0x4b,
-	0x2d, 0xf5, 0x3e, 0xa4, 0x2b, 0xb9, 0x6c, 0x12, 0x3a, 0x17, 0x79, 0x94, 0x8a, 0x54, 0x5c, 0x95,
-	0x67, 0xa4, 0x80, 0xba, 0xb4, 0x7c, 0xf4, 0x1f, 0x81, 0x33, 0x53, 0xe1, 0x69, 0x25, 0x9a, 0x12,
-	0x13, 0xe8, 0xea, 0x7f, 0xd5, 0x04, 0x05, 0x56, 0xd8, 0x8f, 0x5b, 0x88, 0x27, 0x60, 0x67, 0x2c,
-	0xe1, 0x59, 0x4d, 0xcc, 0xc0, 0x0a, 0x9d, 0xf1, 0x3b, 0xda, 0x96, 0xa2, 0x37, 0x01, 0xf4, 0xa7,
-	0xd2, 0x7c, 0x2f, 0x64, 0xb5, 0x8d, 0x2f, 0x06, 0x6f, 0x02, 0xce, 0x0d, 0x8d, 0x5d, 0xb0, 0xd6,
-	0x7c, 0x4b, 0x50, 0x80, 0xc2, 0x7e, 0x7c, 0x3e, 0xf1, 0x1b, 0x78, 0xb5, 0x61, 0x59, 0xc3, 0x89,
-	0xa9, 0x38, 0x0d, 0xbe, 0x98, 0x9f, 0xd1, 0xe8, 0x0e, 0x41, 0xff, 0x17, 0xaf, 0x97, 0xda, 0x89,
-	0xa1, 0x53, 0xb0, 0x9c, 0x5f, 0xac, 0xea, 0xc6, 0x21, 0xd8, 0xb5, 0x64, 0xb2, 0xa9, 0x95, 0xf9,
-	0xf5, 0xd8, 0xbd, 0xf6, 0xfa, 0xad, 0xf8, 0xf8, 0xf2, 0x1d, 0x7f, 0x85, 0x6e, 0x53, 0x2e, 0x98,
-	0xe4, 0x0b, 0x62, 0x05, 0x28, 0x74, 0xc6, 0x1e, 0xd5, 0x6b, 0xd2, 0x76, 0x23, 0x3a, 0x6b, 0xd7,
-	0x9c, 0xf6, 0xf6, 0x0f, 0x43, 0x63, 0xf7, 0x38, 0x44, 0x71, 0x6b, 0xc2, 0x9f, 0xc0, 0x91, 0xd7,
-	0x97, 0x92, 0x8e, 0xca, 0x78, 0xfb, 0xe2, 0x0c, 0xf1, 0xad, 0xf2, 0x7d, 0x04, 0xb6, 0xae, 0x82,
-	0x1d, 0xe8, 0xfe, 0x29, 0xd6, 0x85, 0xf8, 0x57, 0xb8, 0x06, 0x06, 0xb0, 0xbf, 0xcd, 0xe5, 0x6a,
-	0xc3, 0x5d, 0x84, 0x07, 0xd0, 0xfb, 0x51, 0x30, 0x8d, 0xcc, 0x29, 0xd9, 0x1f, 0x7d, 0x74, 0x38,
-	0xfa, 0xe8, 0xe9, 0xe8, 0xa3, 0xdd, 0xc9, 0x37, 0x0e, 0x27, 0xdf, 0xb8, 0x3f, 0xf9, 0x46, 0x62,
-	0xab, 0xaa, 0x1f, 0x9f, 0x03, 0x00, 0x00, 0xff, 0xff, 0xf3, 0x89, 0x75, 0xd4, 0x20, 0x02, 0x00,
-	0x00,
+func (m *Digest) Reset()         { *m = Digest{} }
+func (m *Digest) String() string { return proto.CompactTextString(m) }
+func (*Digest) ProtoMessage()    {}
+func (*Digest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_468528a86129e532, []int{7}
+}
+func (m *Digest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Digest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Digest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Digest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Digest.Merge(m, src)
+}
+func (m *Digest) XXX_Size() int {
+	return m.Size()
+}
+func (m *Digest) XXX_DiscardUnknown() {
+	xxx_messageInfo_Digest.DiscardUnknown(m)
 }
 
-func (m *Targetgroup) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_Digest proto.InternalMessageInfo
+
+func (m *Digest) GetVersions() map[string]Version {
+	if m != nil {
+		return m.Versions
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *Targetgroup) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *Digest) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
 }
 
-func (m *Targetgroup) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+type TargetHealth struct {
+	Job    string `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	Target string `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Up     bool   `protobuf:"varint,3,opt,name=up,proto3" json:"up,omitempty"`
+}
+
+func (m *TargetHealth) Reset()         { *m = TargetHealth{} }
+func (m *TargetHealth) String() string { return proto.CompactTextString(m) }
+func (*TargetHealth) ProtoMessage()    {}
+func (*TargetHealth) Descriptor() ([]byte, []int) {
+	return fileDescriptor_468528a86129e532, []int{8}
+}
+func (m *TargetHealth) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TargetHealth) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TargetHealth.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TargetHealth) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TargetHealth.Merge(m, src)
+}
+func (m *TargetHealth) XXX_Size() int {
+	return m.Size()
+}
+func (m *TargetHealth) XXX_DiscardUnknown() {
+	xxx_messageInfo_TargetHealth.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TargetHealth proto.InternalMessageInfo
+
+func (m *TargetHealth) GetJob() string {
+	if m != nil {
+		return m.Job
+	}
+	return ""
+}
+
+func (m *TargetHealth) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *TargetHealth) GetUp() bool {
+	if m != nil {
+		return m.Up
+	}
+	return false
+}
+
+// HealthReport is the health of the targets probed by a node
+type HealthReport struct {
+	Node    string          `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Updated time.Time       `protobuf:"bytes,2,opt,name=updated,proto3,stdtime" json:"updated"`
+	Targets []*TargetHealth `protobuf:"bytes,3,rep,name=targets,proto3" json:"targets,omitempty"`
+}
+
+func (m *HealthReport) Reset()         { *m = HealthReport{} }
+func (m *HealthReport) String() string { return proto.CompactTextString(m) }
+func (*HealthReport) ProtoMessage()    {}
+func (*HealthReport) Descriptor() ([]byte, []int) {
+	return fileDescriptor_468528a86129e532, []int{9}
+}
+func (m *HealthReport) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *HealthReport) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HealthReport.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *HealthReport) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthReport.Merge(m, src)
+}
+func (m *HealthReport) XXX_Size() int {
+	return m.Size()
+}
+func (m *HealthReport) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthReport.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthReport proto.InternalMessageInfo
+
+func (m *HealthReport) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *HealthReport) GetUpdated() time.Time {
+	if m != nil {
+		return m.Updated
+	}
+	return time.Time{}
+}
+
+func (m *HealthReport) GetTargets() []*TargetHealth {
+	if m != nil {
+		return m.Targets
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("targetpb.Status", Status_name, Status_value)
+	proto.RegisterType((*HTTPProbe)(nil), "targetpb.HTTPProbe")
+	proto.RegisterType((*DNSProbe)(nil), "targetpb.DNSProbe")
+	proto.RegisterType((*Histogram)(nil), "targetpb.Histogram")
+	proto.RegisterType((*Probe)(nil), "targetpb.Probe")
+	proto.RegisterType((*Targetgroup)(nil), "targetpb.Targetgroup")
+	proto.RegisterMapType((map[string]string)(nil), "targetpb.Targetgroup.LabelsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "targetpb.Targetgroup.NodeSelectorEntry")
+	proto.RegisterType((*Version)(nil), "targetpb.Version")
+	proto.RegisterType((*MeshEntry)(nil), "targetpb.MeshEntry")
+	proto.RegisterType((*Digest)(nil), "targetpb.Digest")
+	proto.RegisterMapType((map[string]Version)(nil), "targetpb.Digest.VersionsEntry")
+	proto.RegisterType((*TargetHealth)(nil), "targetpb.TargetHealth")
+	proto.RegisterType((*HealthReport)(nil), "targetpb.HealthReport")
+}
+
+func init() { proto.RegisterFile("target.proto", fileDescriptor_468528a86129e532) }
+
+var fileDescriptor_468528a86129e532 = []byte{
+	// 952 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0x4f, 0x8f, 0xdb, 0x44,
+	0x14, 0x5f, 0x27, 0x9b, 0xc4, 0x7e, 0x4e, 0xda, 0x74, 0x28, 0x95, 0x09, 0x22, 0x9b, 0x06, 0xd0,
+	0x46, 0x95, 0x48, 0xda, 0xe5, 0x00, 0x45, 0x82, 0xd2, 0x68, 0xa9, 0x52, 0xa9, 0x44, 0xd5, 0x6c,
+	0x80, 0x63, 0x34, 0x8e, 0x67, 0x1d, 0x77, 0x1d, 0x8f, 0xb1, 0xc7, 0x59, 0xd2, 0x13, 0x1f, 0x80,
+	0x43, 0x0f, 0x1c, 0xf8, 0x14, 0x7c, 0x8e, 0x1e, 0x7b, 0xe4, 0x04, 0x68, 0xf7, 0x33, 0x70, 0x47,
+	0xf3, 0xc7, 0xb1, 0xe9, 0xe6, 0x50, 0x7a, 0x9b, 0xf7, 0xde, 0xef, 0xf7, 0xfc, 0xde, 0xfb, 0xbd,
+	0x19, 0x43, 0x93, 0x93, 0xc4, 0xa7, 0x7c, 0x18, 0x27, 0x8c, 0x33, 0x64, 0x2a, 0x2b, 0x76, 0x3b,
+	0x5d, 0x9f, 0x31, 0x3f, 0xa4, 0x23, 0xe9, 0x77, 0xb3, 0xd3, 0x91, 0x97, 0x25, 0x84, 0x07, 0x2c,
+	0x52, 0xc8, 0xce, 0xc1, 0xeb, 0x71, 0x1e, 0xac, 0x68, 0xca, 0xc9, 0x2a, 0xd6, 0x80, 0x4f, 0xfc,
+	0x80, 0x2f, 0x33, 0x77, 0xb8, 0x60, 0xab, 0x91, 0xcf, 0x7c, 0x56, 0x20, 0x85, 0x25, 0x0d, 0x79,
+	0x52, 0xf0, 0xfe, 0x1d, 0xb0, 0x26, 0xb3, 0xd9, 0xd3, 0xa7, 0x09, 0x73, 0x29, 0xfa, 0x00, 0xc0,
+	0x65, 0xde, 0x66, 0x9e, 0x50, 0x9f, 0xfe, 0xe4, 0x18, 0x3d, 0x63, 0x60, 0x61, 0x4b, 0x78, 0xb0,
+	0x70, 0xf4, 0x27, 0x60, 0x1e, 0x4f, 0x4f, 0xb6, 0xd0, 0x1f, 0x33, 0x9a, 0x6c, 0xe6, 0x11, 0x59,
+	0xd1, 0x1c, 0x2a, 0x3d, 0x53, 0xb2, 0x2a, 0x85, 0xf9, 0x26, 0xa6, 0x4e, 0xa5, 0x14, 0x9e, 0x6d,
+	0x62, 0xda, 0xff, 0x01, 0xac, 0x49, 0x90, 0x72, 0xe6, 0x27, 0x64, 0x85, 0x1c, 0x68, 0xb8, 0xd9,
+	0xe2, 0x8c, 0xf2, 0xd4, 0x31, 0x7a, 0xd5, 0x81, 0x81, 0x73, 0x13, 0xdd, 0x85, 0x9b, 0x11, 0xe1,
+	0xc1, 0x9a, 0xce, 0x95, 0x67, 0x7e, 0x4a, 0x16, 0x9c, 0x25, 0x32, 0x9f, 0x81, 0x91, 0x8a, 0x8d,
+	0x65, 0xe8, 0x91, 0x8c, 0xf4, 0x7f, 0xde, 0x87, 0x9a, 0x2a, 0x10, 0xc1, 0xbe, 0xfc, 0xb6, 0x2a,
+	0x4d, 0x9e, 0xd1, 0x21, 0xec, 0x2f, 0x39, 0x8f, 0x25, 0xdf, 0x3e, 0x7a, 0x67, 0x98, 0x4f, 0x7d,
+	0xb8, 0x1d, 0x01, 0x96, 0x00, 0xf4, 0x11, 0x54, 0xbd, 0x28, 0x75, 0xaa, 0x12, 0x87, 0x0a, 0x5c,
+	0xde, 0x3e, 0x16, 0x61, 0xf4, 0x00, 0xcc, 0x20, 0xe2, 0x34, 0x59, 0x93, 0xd0, 0xd9, 0x97, 0xd0,
+	0xf7, 0x86, 0x4a, 0x9e, 0x61, 0x3e, 0xf4, 0xe1, 0xb1, 0x96, 0x6f, 0x6c, 0xbe, 0xfc, 0xf3, 0x60,
+	0xef, 0xb7, 0xbf, 0x0e, 0x0c, 0xbc, 0x25, 0xa1, 0x2f, 0xa1, 0x21, 0xe4, 0x63, 0x19, 0x77, 0x6a,
+	0x6f, 0xce, 0xcf, 0x39, 0xe8, 0x36, 0x34, 0x63, 0xb2, 0x09, 0x19, 0xf1, 0xe6, 0x69, 0xf0, 0x9c,
+	0x3a, 0xf5, 0x9e, 0x31, 0x68, 0x61, 0x5b, 0xfb, 0x4e, 0x82, 0xe7, 0x14, 0xb5, 0xa1, 0xca, 0x59,
+	0xea, 0x34, 0x64, 0x44, 0x1c, 0xd1, 0x87, 0xd0, 0xf2, 0x58, 0xc4, 0xe7, 0xa7, 0x09, 0xf1, 0x57,
+	0x34, 0xe2, 0x8e, 0xd9, 0x33, 0x06, 0x26, 0x6e, 0x0a, 0xe7, 0x23, 0xed, 0x43, 0xb7, 0xa0, 0x7e,
+	0x1e, 0x44, 0x1e, 0x3b, 0x77, 0x2c, 0xc9, 0xd4, 0x16, 0xba, 0x07, 0xd6, 0x32, 0xd7, 0xcd, 0x81,
+	0x2b, 0x53, 0xcc, 0x43, 0xb8, 0x40, 0xa1, 0x29, 0xb4, 0x13, 0x9a, 0xb2, 0x70, 0x4d, 0xe7, 0xdb,
+	0x61, 0xd9, 0x6f, 0xde, 0xec, 0x75, 0x4d, 0x7e, 0x9c, 0xcf, 0xec, 0x00, 0xec, 0x3c, 0x1f, 0x09,
+	0x43, 0xa7, 0x29, 0xab, 0x07, 0xed, 0x7a, 0x18, 0x86, 0xfd, 0x5f, 0xaa, 0x60, 0xcf, 0x64, 0x49,
+	0x7e, 0xc2, 0xb2, 0x58, 0xac, 0x97, 0xaa, 0x50, 0xad, 0x97, 0x85, 0x73, 0x13, 0xdd, 0x87, 0x7a,
+	0x48, 0x5c, 0x1a, 0xa6, 0x4e, 0xa5, 0x57, 0x1d, 0xd8, 0x47, 0xb7, 0x8b, 0x56, 0x4a, 0x09, 0x86,
+	0x4f, 0x24, 0xe6, 0x9b, 0x88, 0x27, 0x1b, 0xac, 0x09, 0xe8, 0x63, 0xa8, 0xc5, 0x62, 0x11, 0xf4,
+	0x8a, 0x5c, 0x2f, 0x98, 0x6a, 0x3f, 0x54, 0x14, 0x75, 0xc0, 0x4c, 0x68, 0x1c, 0x06, 0x0b, 0x92,
+	0xca, 0x0d, 0x69, 0xe1, 0xad, 0x8d, 0x9e, 0x40, 0x2b, 0x62, 0x1e, 0x9d, 0xa7, 0x34, 0xa4, 0x72,
+	0xab, 0x6b, 0xb2, 0x88, 0xc3, 0xdd, 0x45, 0x4c, 0x99, 0x47, 0x4f, 0x34, 0x52, 0x95, 0xd2, 0x8c,
+	0x4a, 0x2e, 0xf4, 0x3e, 0x58, 0x69, 0x9c, 0x50, 0xe2, 0xcd, 0xdd, 0x8d, 0x5c, 0x04, 0x0b, 0x9b,
+	0xca, 0x31, 0xde, 0x74, 0xee, 0x83, 0x5d, 0x6a, 0x42, 0x2c, 0xc5, 0x19, 0xdd, 0xe8, 0x9b, 0x21,
+	0x8e, 0xe8, 0x26, 0xd4, 0xd6, 0x24, 0xcc, 0xf2, 0x9b, 0xaa, 0x8c, 0x2f, 0x2a, 0x9f, 0x1b, 0x9d,
+	0x07, 0x70, 0xe3, 0xca, 0xa7, 0xff, 0x4f, 0x82, 0xfe, 0x08, 0x1a, 0xdf, 0xd3, 0x24, 0x0d, 0x58,
+	0x24, 0x68, 0xcb, 0x70, 0x21, 0x69, 0x55, 0x2c, 0x8e, 0xe2, 0x92, 0x8a, 0x2e, 0x34, 0x4b, 0x9e,
+	0xfb, 0xff, 0x18, 0x60, 0x7d, 0x4b, 0xd3, 0xa5, 0xfa, 0x94, 0x40, 0x14, 0x2f, 0x8c, 0x3c, 0xa3,
+	0x01, 0xd4, 0x53, 0x4e, 0x78, 0x96, 0x4a, 0xde, 0xb5, 0xa3, 0x76, 0x31, 0xb2, 0x13, 0xe9, 0xc7,
+	0x3a, 0x8e, 0xbe, 0x82, 0x46, 0x16, 0x7b, 0x84, 0x53, 0x4f, 0x0b, 0xd5, 0xb9, 0xb2, 0x73, 0xb3,
+	0xfc, 0xfd, 0x54, 0x4b, 0xf7, 0x42, 0xde, 0x30, 0x4d, 0x42, 0x9f, 0x81, 0xcd, 0x0b, 0x11, 0xf4,
+	0x25, 0x7f, 0x77, 0xa7, 0x42, 0xb8, 0x8c, 0x44, 0xf7, 0xa0, 0xb1, 0x56, 0x5d, 0xeb, 0x9b, 0x7d,
+	0xa3, 0x20, 0xe9, 0x71, 0x8c, 0xf7, 0xc5, 0xf7, 0x70, 0x8e, 0xeb, 0xff, 0x6e, 0x40, 0xfd, 0x38,
+	0xf0, 0x69, 0xca, 0xd1, 0xd7, 0x60, 0x6a, 0xaf, 0xda, 0x59, 0xfb, 0xa8, 0x5b, 0x7a, 0x83, 0x24,
+	0x26, 0xcf, 0xa2, 0x24, 0xd5, 0xb9, 0xb6, 0xac, 0x5d, 0x83, 0xed, 0x4c, 0xa1, 0xf5, 0x1f, 0xd2,
+	0x0e, 0x19, 0x0f, 0xcb, 0x32, 0xee, 0x2a, 0xba, 0xac, 0xec, 0x04, 0x9a, 0xaa, 0xff, 0x09, 0x25,
+	0x21, 0x5f, 0x8a, 0x74, 0xcf, 0x98, 0x9b, 0xa7, 0x7b, 0xc6, 0x5c, 0xf1, 0x8c, 0xa8, 0x04, 0xba,
+	0x0e, 0x6d, 0xa1, 0x6b, 0x50, 0xc9, 0x62, 0xa9, 0x88, 0x89, 0x2b, 0x59, 0xdc, 0xff, 0xd5, 0x80,
+	0xa6, 0x4a, 0x82, 0x69, 0xcc, 0x12, 0xbe, 0x2d, 0xdf, 0x28, 0xca, 0x2f, 0x6b, 0x59, 0x79, 0x1b,
+	0x2d, 0xef, 0x16, 0xef, 0x40, 0x55, 0xce, 0xf4, 0xd6, 0xeb, 0x3a, 0xea, 0x12, 0x72, 0xd8, 0x9d,
+	0x11, 0xd4, 0xd5, 0x3e, 0x21, 0x1b, 0x1a, 0xdf, 0x45, 0x67, 0x11, 0x3b, 0x8f, 0xda, 0x7b, 0x08,
+	0xa0, 0xfe, 0x70, 0x21, 0xfe, 0x3c, 0x6d, 0x03, 0x35, 0xc1, 0x7c, 0x1c, 0x11, 0x65, 0x55, 0xc6,
+	0xce, 0xcb, 0x8b, 0xae, 0xf1, 0xea, 0xa2, 0x6b, 0xfc, 0x7d, 0xd1, 0x35, 0x5e, 0x5c, 0x76, 0xf7,
+	0x5e, 0x5d, 0x76, 0xf7, 0xfe, 0xb8, 0xec, 0xee, 0xb9, 0x75, 0x59, 0xe3, 0xa7, 0xff, 0x0e, 0x00,
+	0x07, 0x06, 0xc2, 0x5b, 0xf7, 0x07, 0x00, 0x00,
+}
+
+func (m *HTTPProbe) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HTTPProbe) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HTTPProbe) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.Labels) > 0 {
-		for k := range m.Labels {
-			v := m.Labels[k]
-			baseI := i
-			i -= len(v)
-			copy(dAtA[i:], v)
-			i = encodeVarintTarget(dAtA, i, uint64(len(v)))
-			i--
-			dAtA[i] = 0x12
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintTarget(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintTarget(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x12
-		}
+	if len(m.BodyRegex) > 0 {
+		i -= len(m.BodyRegex)
+		copy(dAtA[i:], m.BodyRegex)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.BodyRegex)))
+		i--
+		dAtA[i] = 0xa
 	}
-	if len(m.Targets) > 0 {
-		for iNdEx := len(m.Targets) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.Targets[iNdEx])
-			copy(dAtA[i:], m.Targets[iNdEx])
-			i = encodeVarintTarget(dAtA, i, uint64(len(m.Targets[iNdEx])))
-			i--
-			dAtA[i] = 0xa
+	return len(dAtA) - i, nil
+}
+
+func (m *DNSProbe) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DNSProbe) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DNSProbe) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.QueryType) > 0 {
+		i -= len(m.QueryType)
+		copy(dAtA[i:], m.QueryType)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.QueryType)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.QueryName) > 0 {
+		i -= len(m.QueryName)
+		copy(dAtA[i:], m.QueryName)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.QueryName)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Histogram) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Histogram) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Histogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.NativeBucketFactor != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.NativeBucketFactor))))
+		i--
+		dAtA[i] = 0x11
+	}
+	if len(m.Buckets) > 0 {
+		for iNdEx := len(m.Buckets) - 1; iNdEx >= 0; iNdEx-- {
+			f1 := math.Float64bits(float64(m.Buckets[iNdEx]))
+			i -= 8
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f1))
 		}
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.Buckets)*8))
+		i--
+		dAtA[i] = 0xa
 	}
 	return len(dAtA) - i, nil
 }
 
-func (m *MeshEntry) Marshal() (dAtA []byte, err error) {
+func (m *Probe) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -273,19 +951,37 @@ func (m *MeshEntry) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *MeshEntry) MarshalTo(dAtA []byte) (int, error) {
+func (m *Probe) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *MeshEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *Probe) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.Targetgroup != nil {
+	if m.ResolveAll {
+		i--
+		if m.ResolveAll {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x60
+	}
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ResolveInterval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.ResolveInterval):])
+	if err2 != nil {
+		return 0, err2
+	}
+	i -= n2
+	i = encodeVarintTarget(dAtA, i, uint64(n2))
+	i--
+	dAtA[i] = 0x5a
+	if m.Histogram != nil {
 		{
-			size, err := m.Targetgroup.MarshalToSizedBuffer(dAtA[:i])
+			size, err := m.Histogram.MarshalToSizedBuffer(dAtA[:i])
 			if err != nil {
 				return 0, err
 			}
@@ -293,94 +989,2108 @@ func (m *MeshEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			i = encodeVarintTarget(dAtA, i, uint64(size))
 		}
 		i--
-		dAtA[i] = 0x22
+		dAtA[i] = 0x52
 	}
-	n2, err2 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Updated, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Updated):])
-	if err2 != nil {
-		return 0, err2
+	if m.Window != 0 {
+		i = encodeVarintTarget(dAtA, i, uint64(m.Window))
+		i--
+		dAtA[i] = 0x48
 	}
-	i -= n2
-	i = encodeVarintTarget(dAtA, i, uint64(n2))
+	if m.DontFragment {
+		i--
+		if m.DontFragment {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.Tos != 0 {
+		i = encodeVarintTarget(dAtA, i, uint64(m.Tos))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.PayloadSize != 0 {
+		i = encodeVarintTarget(dAtA, i, uint64(m.PayloadSize))
+		i--
+		dAtA[i] = 0x30
+	}
+	n4, err4 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Timeout, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout):])
+	if err4 != nil {
+		return 0, err4
+	}
+	i -= n4
+	i = encodeVarintTarget(dAtA, i, uint64(n4))
 	i--
-	dAtA[i] = 0x1a
-	if m.Status != 0 {
-		i = encodeVarintTarget(dAtA, i, uint64(m.Status))
+	dAtA[i] = 0x2a
+	n5, err5 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Interval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Interval):])
+	if err5 != nil {
+		return 0, err5
+	}
+	i -= n5
+	i = encodeVarintTarget(dAtA, i, uint64(n5))
+	i--
+	dAtA[i] = 0x22
+	if m.Dns != nil {
+		{
+			size, err := m.Dns.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTarget(dAtA, i, uint64(size))
+		}
 		i--
-		dAtA[i] = 0x10
+		dAtA[i] = 0x1a
 	}
-	if len(m.Name) > 0 {
-		i -= len(m.Name)
-		copy(dAtA[i:], m.Name)
-		i = encodeVarintTarget(dAtA, i, uint64(len(m.Name)))
+	if m.Http != nil {
+		{
+			size, err := m.Http.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTarget(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Type) > 0 {
+		i -= len(m.Type)
+		copy(dAtA[i:], m.Type)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.Type)))
 		i--
 		dAtA[i] = 0xa
 	}
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintTarget(dAtA []byte, offset int, v uint64) int {
-	offset -= sovTarget(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *Targetgroup) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *Targetgroup) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Targets) > 0 {
-		for _, s := range m.Targets {
-			l = len(s)
-			n += 1 + l + sovTarget(uint64(l))
+
+func (m *Targetgroup) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Targetgroup) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.SpreadBy) > 0 {
+		i -= len(m.SpreadBy)
+		copy(dAtA[i:], m.SpreadBy)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.SpreadBy)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.NodeSelector) > 0 {
+		for k := range m.NodeSelector {
+			v := m.NodeSelector[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintTarget(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintTarget(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintTarget(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if m.Replicas != 0 {
+		i = encodeVarintTarget(dAtA, i, uint64(m.Replicas))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Probe != nil {
+		{
+			size, err := m.Probe.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTarget(dAtA, i, uint64(size))
 		}
+		i--
+		dAtA[i] = 0x1a
 	}
 	if len(m.Labels) > 0 {
-		for k, v := range m.Labels {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovTarget(uint64(len(k))) + 1 + len(v) + sovTarget(uint64(len(v)))
-			n += mapEntrySize + 1 + sovTarget(uint64(mapEntrySize))
+		for k := range m.Labels {
+			v := m.Labels[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintTarget(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintTarget(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintTarget(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Targets) > 0 {
+		for iNdEx := len(m.Targets) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Targets[iNdEx])
+			copy(dAtA[i:], m.Targets[iNdEx])
+			i = encodeVarintTarget(dAtA, i, uint64(len(m.Targets[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Version) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Version) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Version) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Node) > 0 {
+		i -= len(m.Node)
+		copy(dAtA[i:], m.Node)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.Node)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Hlc != 0 {
+		i = encodeVarintTarget(dAtA, i, uint64(m.Hlc))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MeshEntry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MeshEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MeshEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size, err := m.Version.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintTarget(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x2a
+	if m.Targetgroup != nil {
+		{
+			size, err := m.Targetgroup.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTarget(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	n11, err11 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Updated, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Updated):])
+	if err11 != nil {
+		return 0, err11
+	}
+	i -= n11
+	i = encodeVarintTarget(dAtA, i, uint64(n11))
+	i--
+	dAtA[i] = 0x1a
+	if m.Status != 0 {
+		i = encodeVarintTarget(dAtA, i, uint64(m.Status))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Digest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Digest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Digest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Node) > 0 {
+		i -= len(m.Node)
+		copy(dAtA[i:], m.Node)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.Node)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Versions) > 0 {
+		for k := range m.Versions {
+			v := m.Versions[k]
+			baseI := i
+			{
+				size, err := (&v).MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTarget(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintTarget(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintTarget(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TargetHealth) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TargetHealth) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TargetHealth) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Up {
+		i--
+		if m.Up {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Target) > 0 {
+		i -= len(m.Target)
+		copy(dAtA[i:], m.Target)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.Target)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Job) > 0 {
+		i -= len(m.Job)
+		copy(dAtA[i:], m.Job)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.Job)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HealthReport) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HealthReport) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HealthReport) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Targets) > 0 {
+		for iNdEx := len(m.Targets) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Targets[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTarget(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	n13, err13 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Updated, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Updated):])
+	if err13 != nil {
+		return 0, err13
+	}
+	i -= n13
+	i = encodeVarintTarget(dAtA, i, uint64(n13))
+	i--
+	dAtA[i] = 0x12
+	if len(m.Node) > 0 {
+		i -= len(m.Node)
+		copy(dAtA[i:], m.Node)
+		i = encodeVarintTarget(dAtA, i, uint64(len(m.Node)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintTarget(dAtA []byte, offset int, v uint64) int {
+	offset -= sovTarget(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *HTTPProbe) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.BodyRegex)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	return n
+}
+
+func (m *DNSProbe) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.QueryName)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	l = len(m.QueryType)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	return n
+}
+
+func (m *Histogram) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Buckets) > 0 {
+		n += 1 + sovTarget(uint64(len(m.Buckets)*8)) + len(m.Buckets)*8
+	}
+	if m.NativeBucketFactor != 0 {
+		n += 9
+	}
+	return n
+}
+
+func (m *Probe) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Type)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	if m.Http != nil {
+		l = m.Http.Size()
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	if m.Dns != nil {
+		l = m.Dns.Size()
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Interval)
+	n += 1 + l + sovTarget(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Timeout)
+	n += 1 + l + sovTarget(uint64(l))
+	if m.PayloadSize != 0 {
+		n += 1 + sovTarget(uint64(m.PayloadSize))
+	}
+	if m.Tos != 0 {
+		n += 1 + sovTarget(uint64(m.Tos))
+	}
+	if m.DontFragment {
+		n += 2
+	}
+	if m.Window != 0 {
+		n += 1 + sovTarget(uint64(m.Window))
+	}
+	if m.Histogram != nil {
+		l = m.Histogram.Size()
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.ResolveInterval)
+	n += 1 + l + sovTarget(uint64(l))
+	if m.ResolveAll {
+		n += 2
+	}
+	return n
+}
+
+func (m *Targetgroup) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Targets) > 0 {
+		for _, s := range m.Targets {
+			l = len(s)
+			n += 1 + l + sovTarget(uint64(l))
+		}
+	}
+	if len(m.Labels) > 0 {
+		for k, v := range m.Labels {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovTarget(uint64(len(k))) + 1 + len(v) + sovTarget(uint64(len(v)))
+			n += mapEntrySize + 1 + sovTarget(uint64(mapEntrySize))
+		}
+	}
+	if m.Probe != nil {
+		l = m.Probe.Size()
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	if m.Replicas != 0 {
+		n += 1 + sovTarget(uint64(m.Replicas))
+	}
+	if len(m.NodeSelector) > 0 {
+		for k, v := range m.NodeSelector {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovTarget(uint64(len(k))) + 1 + len(v) + sovTarget(uint64(len(v)))
+			n += mapEntrySize + 1 + sovTarget(uint64(mapEntrySize))
+		}
+	}
+	l = len(m.SpreadBy)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	return n
+}
+
+func (m *Version) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Hlc != 0 {
+		n += 1 + sovTarget(uint64(m.Hlc))
+	}
+	l = len(m.Node)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	return n
+}
+
+func (m *MeshEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	if m.Status != 0 {
+		n += 1 + sovTarget(uint64(m.Status))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.Updated)
+	n += 1 + l + sovTarget(uint64(l))
+	if m.Targetgroup != nil {
+		l = m.Targetgroup.Size()
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	l = m.Version.Size()
+	n += 1 + l + sovTarget(uint64(l))
+	return n
+}
+
+func (m *Digest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Versions) > 0 {
+		for k, v := range m.Versions {
+			_ = k
+			_ = v
+			l = v.Size()
+			mapEntrySize := 1 + len(k) + sovTarget(uint64(len(k))) + 1 + l + sovTarget(uint64(l))
+			n += mapEntrySize + 1 + sovTarget(uint64(mapEntrySize))
+		}
+	}
+	l = len(m.Node)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	return n
+}
+
+func (m *TargetHealth) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Job)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	if m.Up {
+		n += 2
+	}
+	return n
+}
+
+func (m *HealthReport) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Node)
+	if l > 0 {
+		n += 1 + l + sovTarget(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.Updated)
+	n += 1 + l + sovTarget(uint64(l))
+	if len(m.Targets) > 0 {
+		for _, e := range m.Targets {
+			l = e.Size()
+			n += 1 + l + sovTarget(uint64(l))
+		}
+	}
+	return n
+}
+
+func sovTarget(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozTarget(x uint64) (n int) {
+	return sovTarget(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *HTTPProbe) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTarget
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: HTTPProbe: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: HTTPProbe: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BodyRegex", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BodyRegex = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTarget(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DNSProbe) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTarget
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DNSProbe: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DNSProbe: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QueryName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QueryType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTarget(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Histogram) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTarget
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Histogram: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Histogram: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 1 {
+				var v uint64
+				if (iNdEx + 8) > l {
+					return io.ErrUnexpectedEOF
+				}
+				v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+				iNdEx += 8
+				v2 := float64(math.Float64frombits(v))
+				m.Buckets = append(m.Buckets, v2)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTarget
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthTarget
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthTarget
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				elementCount = packedLen / 8
+				if elementCount != 0 && len(m.Buckets) == 0 {
+					m.Buckets = make([]float64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					if (iNdEx + 8) > l {
+						return io.ErrUnexpectedEOF
+					}
+					v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+					iNdEx += 8
+					v2 := float64(math.Float64frombits(v))
+					m.Buckets = append(m.Buckets, v2)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Buckets", wireType)
+			}
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NativeBucketFactor", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.NativeBucketFactor = float64(math.Float64frombits(v))
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTarget(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Probe) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTarget
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Probe: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Probe: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Type = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Http", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Http == nil {
+				m.Http = &HTTPProbe{}
+			}
+			if err := m.Http.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Dns", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Dns == nil {
+				m.Dns = &DNSProbe{}
+			}
+			if err := m.Dns.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Interval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Interval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeout", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Timeout, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PayloadSize", wireType)
+			}
+			m.PayloadSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PayloadSize |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tos", wireType)
+			}
+			m.Tos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Tos |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DontFragment", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.DontFragment = bool(v != 0)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Window", wireType)
+			}
+			m.Window = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Window |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Histogram", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Histogram == nil {
+				m.Histogram = &Histogram{}
+			}
+			if err := m.Histogram.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResolveInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.ResolveInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResolveAll", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ResolveAll = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTarget(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Targetgroup) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTarget
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Targetgroup: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Targetgroup: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Targets", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Targets = append(m.Targets, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Labels == nil {
+				m.Labels = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTarget
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTarget
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthTarget
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthTarget
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTarget
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthTarget
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthTarget
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipTarget(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthTarget
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Labels[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Probe", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Probe == nil {
+				m.Probe = &Probe{}
+			}
+			if err := m.Probe.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replicas", wireType)
+			}
+			m.Replicas = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Replicas |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NodeSelector", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.NodeSelector == nil {
+				m.NodeSelector = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTarget
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTarget
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthTarget
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthTarget
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTarget
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthTarget
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthTarget
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipTarget(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthTarget
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.NodeSelector[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SpreadBy", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SpreadBy = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTarget(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Version) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTarget
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Version: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Version: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hlc", wireType)
+			}
+			m.Hlc = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Hlc |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Node", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Node = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTarget(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MeshEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTarget
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MeshEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MeshEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			m.Status = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Status |= Status(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Updated", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdTimeUnmarshal(&m.Updated, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Targetgroup", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Targetgroup == nil {
+				m.Targetgroup = &Targetgroup{}
+			}
+			if err := m.Targetgroup.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Version.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTarget(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
 
-func (m *MeshEntry) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.Name)
-	if l > 0 {
-		n += 1 + l + sovTarget(uint64(l))
-	}
-	if m.Status != 0 {
-		n += 1 + sovTarget(uint64(m.Status))
-	}
-	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.Updated)
-	n += 1 + l + sovTarget(uint64(l))
-	if m.Targetgroup != nil {
-		l = m.Targetgroup.Size()
-		n += 1 + l + sovTarget(uint64(l))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-
-func sovTarget(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozTarget(x uint64) (n int) {
-	return sovTarget(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *Targetgroup) Unmarshal(dAtA []byte) error {
+func (m *Digest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -403,47 +3113,15 @@ func (m *Targetgroup) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Targetgroup: wiretype end group for non-group")
+			return fmt.Errorf("proto: Digest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Targetgroup: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Digest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Targets", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowTarget
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthTarget
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthTarget
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Targets = append(m.Targets, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Versions", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -470,11 +3148,11 @@ func (m *Targetgroup) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Labels == nil {
-				m.Labels = make(map[string]string)
+			if m.Versions == nil {
+				m.Versions = make(map[string]Version)
 			}
 			var mapkey string
-			var mapvalue string
+			mapvalue := &Version{}
 			for iNdEx < postIndex {
 				entryPreIndex := iNdEx
 				var wire uint64
@@ -523,7 +3201,7 @@ func (m *Targetgroup) Unmarshal(dAtA []byte) error {
 					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
 					iNdEx = postStringIndexmapkey
 				} else if fieldNum == 2 {
-					var stringLenmapvalue uint64
+					var mapmsglen int
 					for shift := uint(0); ; shift += 7 {
 						if shift >= 64 {
 							return ErrIntOverflowTarget
@@ -533,31 +3211,33 @@ func (m *Targetgroup) Unmarshal(dAtA []byte) error {
 						}
 						b := dAtA[iNdEx]
 						iNdEx++
-						stringLenmapvalue |= uint64(b&0x7F) << shift
+						mapmsglen |= int(b&0x7F) << shift
 						if b < 0x80 {
 							break
 						}
 					}
-					intStringLenmapvalue := int(stringLenmapvalue)
-					if intStringLenmapvalue < 0 {
+					if mapmsglen < 0 {
 						return ErrInvalidLengthTarget
 					}
-					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
-					if postStringIndexmapvalue < 0 {
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
 						return ErrInvalidLengthTarget
 					}
-					if postStringIndexmapvalue > l {
+					if postmsgIndex > l {
 						return io.ErrUnexpectedEOF
 					}
-					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
-					iNdEx = postStringIndexmapvalue
+					mapvalue = &Version{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
 				} else {
 					iNdEx = entryPreIndex
 					skippy, err := skipTarget(dAtA[iNdEx:])
 					if err != nil {
 						return err
 					}
-					if skippy < 0 {
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
 						return ErrInvalidLengthTarget
 					}
 					if (iNdEx + skippy) > postIndex {
@@ -566,7 +3246,39 @@ func (m *Targetgroup) Unmarshal(dAtA []byte) error {
 					iNdEx += skippy
 				}
 			}
-			m.Labels[mapkey] = mapvalue
+			m.Versions[mapkey] = *mapvalue
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Node", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Node = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -574,10 +3286,7 @@ func (m *Targetgroup) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthTarget
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthTarget
 			}
 			if (iNdEx + skippy) > l {
@@ -592,7 +3301,7 @@ func (m *Targetgroup) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MeshEntry) Unmarshal(dAtA []byte) error {
+func (m *TargetHealth) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -615,15 +3324,15 @@ func (m *MeshEntry) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MeshEntry: wiretype end group for non-group")
+			return fmt.Errorf("proto: TargetHealth: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MeshEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TargetHealth: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Job", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -651,13 +3360,13 @@ func (m *MeshEntry) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Name = string(dAtA[iNdEx:postIndex])
+			m.Job = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
-			m.Status = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowTarget
@@ -667,12 +3376,127 @@ func (m *MeshEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Status |= Status(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Target = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Up", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Up = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTarget(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *HealthReport) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTarget
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: HealthReport: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: HealthReport: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Node", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTarget
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTarget
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTarget
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Node = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Updated", wireType)
 			}
@@ -705,9 +3529,9 @@ func (m *MeshEntry) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 4:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Targetgroup", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Targets", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -734,10 +3558,8 @@ func (m *MeshEntry) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Targetgroup == nil {
-				m.Targetgroup = &Targetgroup{}
-			}
-			if err := m.Targetgroup.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Targets = append(m.Targets, &TargetHealth{})
+			if err := m.Targets[len(m.Targets)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -747,10 +3569,7 @@ func (m *MeshEntry) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthTarget
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthTarget
 			}
 			if (iNdEx + skippy) > l {
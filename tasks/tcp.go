@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	tcpInterval = time.Second
+	tcpTimeout  = time.Second
+)
+
+// tcpProber measures how long it takes to establish a TCP connection
+// to the target, the connection is closed as soon as it is established.
+type tcpProber struct {
+	address  string
+	interval time.Duration
+	timeout  time.Duration
+
+	stopc chan struct{}
+
+	healthState
+
+	// metrics
+	connects        prometheus.Counter
+	connectFailures prometheus.Counter
+	connectTimeouts prometheus.Counter
+	connectDuration prometheus.Histogram
+}
+
+func newTCPProber(target Target) (Prober, error) {
+	addr := target.Address
+
+	_, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	constLabels := target.ConstLabels()
+
+	connects := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "tcp",
+		Name:        "connect_total",
+		Help:        "The number of connect attempts",
+		ConstLabels: constLabels,
+	})
+
+	connectFailures := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "tcp",
+		Name:        "connect_failures_total",
+		Help:        "The number of failed connect attempts, timeouts included",
+		ConstLabels: constLabels,
+	})
+
+	connectTimeouts := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "tcp",
+		Name:        "connect_timeouts_total",
+		Help:        "The number of connect attempts that timed out",
+		ConstLabels: constLabels,
+	})
+
+	connectDuration := prometheus.NewHistogram(target.HistogramOpts(prometheus.HistogramOpts{
+		Namespace:   "gossiping",
+		Subsystem:   "tcp",
+		Name:        "connect_seconds",
+		Help:        "Time taken to establish the connection",
+		ConstLabels: constLabels,
+	}))
+
+	return &tcpProber{
+		address:         addr,
+		interval:        target.Interval(tcpInterval),
+		timeout:         target.Timeout(tcpTimeout),
+		stopc:           make(chan struct{}),
+		connects:        connects,
+		connectFailures: connectFailures,
+		connectTimeouts: connectTimeouts,
+		connectDuration: connectDuration,
+	}, nil
+}
+
+func (p *tcpProber) Describe(descs chan<- *prometheus.Desc) {
+	descs <- p.connects.Desc()
+	descs <- p.connectFailures.Desc()
+	descs <- p.connectTimeouts.Desc()
+	descs <- p.connectDuration.Desc()
+}
+
+func (p *tcpProber) Collect(metrics chan<- prometheus.Metric) {
+	p.connects.Collect(metrics)
+	p.connectFailures.Collect(metrics)
+	p.connectTimeouts.Collect(metrics)
+	p.connectDuration.Collect(metrics)
+}
+
+func (p *tcpProber) Start(logger *zap.Logger) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			logger.Error("task panicked",
+				zap.Stack("task"))
+		}
+	}()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.connect(logger)
+
+		select {
+		case <-p.stopc:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *tcpProber) connect(logger *zap.Logger) {
+	p.connects.Inc()
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", p.address, p.timeout)
+	p.setHealth(err == nil)
+	if err != nil {
+		p.connectFailures.Inc()
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			p.connectTimeouts.Inc()
+		}
+
+		logger.Debug("tcp connect failed",
+			zap.String("addr", p.address),
+			zap.Error(err))
+		return
+	}
+
+	p.connectDuration.Observe(time.Since(start).Seconds())
+	conn.Close()
+}
+
+func (p *tcpProber) Stop() {
+	close(p.stopc)
+}
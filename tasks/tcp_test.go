@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTCPProber(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	newProber := func(addr string, timeout time.Duration) *tcpProber {
+		prober, err := newTCPProber(Target{
+			Address: addr,
+			Probe:   &targetpb.Probe{Type: "tcp", Timeout: timeout},
+		})
+		require.NoError(t, err)
+		return prober.(*tcpProber)
+	}
+
+	p := newProber(ln.Addr().String(), time.Second)
+	p.connect(zap.NewNop())
+	require.Equal(t, HealthUp, p.Health())
+	require.Equal(t, float64(1), testutil.ToFloat64(p.connects))
+	require.Equal(t, float64(0), testutil.ToFloat64(p.connectFailures))
+	require.Equal(t, 1, testutil.CollectAndCount(p.connectDuration))
+
+	// the deadline passes before the connection is established
+	timeout := newProber(ln.Addr().String(), time.Nanosecond)
+	timeout.connect(zap.NewNop())
+	require.Equal(t, HealthDown, timeout.Health())
+	require.Equal(t, float64(1), testutil.ToFloat64(timeout.connectFailures))
+	require.Equal(t, float64(1), testutil.ToFloat64(timeout.connectTimeouts))
+
+	// nothing listens on the closed port
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := closed.Addr().String()
+	require.NoError(t, closed.Close())
+
+	refused := newProber(addr, time.Second)
+	refused.connect(zap.NewNop())
+	require.Equal(t, HealthDown, refused.Health())
+	require.Equal(t, float64(1), testutil.ToFloat64(refused.connectFailures))
+	require.Equal(t, float64(0), testutil.ToFloat64(refused.connectTimeouts))
+
+	_, err = newTCPProber(Target{Address: "127.0.0.1"})
+	require.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+)
+
+// DefaultTombstoneRetention is how long deleted jobs are kept at most
+const DefaultTombstoneRetention = 24 * time.Hour
+
+// tombstone tracks a deleted entry and the peers seen it. Once purged, the
+// entry is dropped from the store, but its version is kept for another
+// retention, so the older versions still gossiped by peers are rejected
+// rather than resurrected.
+type tombstone struct {
+	entry *targetpb.MeshEntry
+
+	// the clocks of nodes might be skewed, so tombstones expire by
+	// local time
+	since    time.Time
+	purgedAt time.Time
+	acks     map[string]struct{}
+}
+
+func newTombstone(me *targetpb.MeshEntry) *tombstone {
+	return &tombstone{
+		entry: me,
+		since: time.Now(),
+		acks:  make(map[string]struct{}),
+	}
+}
+
+func (ts *tombstone) purged() bool {
+	return !ts.purgedAt.IsZero()
+}
+
+func (ts *tombstone) ack(node string) {
+	if node == "" || ts.purged() {
+		return
+	}
+
+	ts.acks[node] = struct{}{}
+}
+
+func (ts *tombstone) seenBy(peers []string) bool {
+	for _, peer := range peers {
+		if _, ok := ts.acks[peer]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GC purges the tombstones seen by all the peers or older than the
+// retention, peers are the alive members but the local node. The purged
+// entries are returned.
+func (s *Store) GC(peers []string, retention time.Duration) []*targetpb.MeshEntry {
+	now := time.Now()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var purged []*targetpb.MeshEntry
+	for name, ts := range s.tombstones {
+		if ts.purged() {
+			if now.Sub(ts.purgedAt) > retention {
+				delete(s.tombstones, name)
+			}
+
+			continue
+		}
+
+		if now.Sub(ts.since) <= retention && !ts.seenBy(peers) {
+			continue
+		}
+
+		delete(s.entries, name)
+		ts.purgedAt = now
+		ts.acks = nil
+		purged = append(purged, ts.entry)
+	}
+
+	return purged
+}
@@ -0,0 +1,268 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultQuorum means a target is down if it's down from at least
+	// half of its probers
+	DefaultQuorum = 0.5
+
+	DefaultReportInterval = 15 * time.Second
+
+	// reports not updated for this number of intervals are dropped, e.g.
+	// the node left or stopped probing
+	reportExpiry = 4
+
+	// reports expire by the time they are made, which is tolerated to be
+	// this far off the local clock
+	maxClockSkew = time.Minute
+)
+
+// Verdict is the health of a target agreed by its probers
+type Verdict struct {
+	Job     string `json:"job"`
+	Target  string `json:"target"`
+	Up      bool   `json:"up"`
+	Probers int    `json:"probers"`
+	Down    int    `json:"down"`
+}
+
+// Verdicts gossips the health of the targets probed by every node, so
+// every node can tell a target failure from a prober failure. It
+// implements cluster.State.
+type Verdicts struct {
+	logger    *zap.Logger
+	collector *Collector
+	node      string
+	quorum    float64
+	interval  time.Duration
+
+	mtx     sync.RWMutex
+	reports map[string]*targetpb.HealthReport
+
+	upDesc      *prometheus.Desc
+	probersDesc *prometheus.Desc
+	downDesc    *prometheus.Desc
+}
+
+// NewVerdicts creates Verdicts, node is the name of this node, and a target
+// is down if the ratio of its probers seeing it down is not less than quorum.
+func NewVerdicts(logger *zap.Logger, collector *Collector, node string, quorum float64, interval time.Duration) *Verdicts {
+	labels := []string{"job", "target"}
+
+	return &Verdicts{
+		logger:    logger,
+		collector: collector,
+		node:      node,
+		quorum:    quorum,
+		interval:  interval,
+		reports:   make(map[string]*targetpb.HealthReport),
+		upDesc: prometheus.NewDesc(
+			"gossiping_target_verdict_up",
+			"Whether the target is up, it is down only if the quorum of its probers see it down",
+			labels, nil),
+		probersDesc: prometheus.NewDesc(
+			"gossiping_target_verdict_probers",
+			"The number of nodes reporting the health of the target",
+			labels, nil),
+		downDesc: prometheus.NewDesc(
+			"gossiping_target_verdict_down_probers",
+			"The number of nodes reporting the target is down",
+			labels, nil),
+	}
+}
+
+// Run reports the health of the targets probed by this node and drops
+// the expired reports periodically, until ctx is done.
+func (v *Verdicts) Run(ctx context.Context, broadcast func(b []byte)) {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		hr := &targetpb.HealthReport{
+			Node:    v.node,
+			Updated: now,
+			Targets: v.collector.Health(),
+		}
+
+		buf := bytes.NewBuffer(nil)
+		_, err := pbutil.WriteDelimited(buf, hr)
+		if err != nil {
+			v.logger.Warn("encode health report failed",
+				zap.Error(err))
+			continue
+		}
+
+		v.mtx.Lock()
+		v.prune(now)
+		v.merge(hr, now)
+		v.mtx.Unlock()
+
+		broadcast(buf.Bytes())
+	}
+}
+
+func (v *Verdicts) MarshalBinary() ([]byte, error) {
+	v.mtx.RLock()
+	defer v.mtx.RUnlock()
+
+	now := time.Now()
+	buf := bytes.NewBuffer(nil)
+	for _, hr := range v.reports {
+		// never pass on the reports expired but not pruned yet
+		if v.expired(hr, now) {
+			continue
+		}
+
+		_, err := pbutil.WriteDelimited(buf, hr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (v *Verdicts) Merge(b []byte) error {
+	var buf = bytes.NewBuffer(b)
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	now := time.Now()
+	for {
+		var hr targetpb.HealthReport
+		_, err := pbutil.ReadDelimited(buf, &hr)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		v.merge(&hr, now)
+	}
+}
+
+// merge keeps the report if it's newer than the known one, the expired
+// reports still held by other nodes are rejected, so they don't come back
+// once pruned.
+func (v *Verdicts) merge(hr *targetpb.HealthReport, now time.Time) {
+	if v.expired(hr, now) {
+		return
+	}
+
+	prev := v.reports[hr.Node]
+	if prev != nil && !hr.Updated.After(prev.Updated) {
+		return
+	}
+
+	v.reports[hr.Node] = hr
+}
+
+// expired reports whether the report is too old to count, the node made
+// it either left or stopped probing.
+func (v *Verdicts) expired(hr *targetpb.HealthReport, now time.Time) bool {
+	return now.Sub(hr.Updated) > reportExpiry*v.interval+maxClockSkew
+}
+
+// prune drops the expired reports
+func (v *Verdicts) prune(now time.Time) {
+	for node, hr := range v.reports {
+		if v.expired(hr, now) {
+			delete(v.reports, node)
+		}
+	}
+}
+
+// Verdicts returns the verdicts of all targets reported by any node,
+// sorted by job and target.
+func (v *Verdicts) Verdicts() []Verdict {
+	v.mtx.RLock()
+	defer v.mtx.RUnlock()
+
+	type key struct {
+		job    string
+		target string
+	}
+
+	now := time.Now()
+	counts := make(map[key]*Verdict)
+	for _, hr := range v.reports {
+		if v.expired(hr, now) {
+			continue
+		}
+
+		for _, th := range hr.Targets {
+			k := key{job: th.Job, target: th.Target}
+			verdict := counts[k]
+			if verdict == nil {
+				verdict = &Verdict{Job: th.Job, Target: th.Target}
+				counts[k] = verdict
+			}
+
+			verdict.Probers++
+			if !th.Up {
+				verdict.Down++
+			}
+		}
+	}
+
+	verdicts := make([]Verdict, 0, len(counts))
+	for _, verdict := range counts {
+		verdict.Up = float64(verdict.Down) < v.quorum*float64(verdict.Probers)
+		verdicts = append(verdicts, *verdict)
+	}
+
+	sort.Slice(verdicts, func(i, j int) bool {
+		if verdicts[i].Job != verdicts[j].Job {
+			return verdicts[i].Job < verdicts[j].Job
+		}
+
+		return verdicts[i].Target < verdicts[j].Target
+	})
+
+	return verdicts
+}
+
+func (v *Verdicts) Describe(descs chan<- *prometheus.Desc) {
+	descs <- v.upDesc
+	descs <- v.probersDesc
+	descs <- v.downDesc
+}
+
+func (v *Verdicts) Collect(metrics chan<- prometheus.Metric) {
+	for _, verdict := range v.Verdicts() {
+		up := 0.0
+		if verdict.Up {
+			up = 1
+		}
+
+		metrics <- prometheus.MustNewConstMetric(v.upDesc, prometheus.GaugeValue,
+			up, verdict.Job, verdict.Target)
+		metrics <- prometheus.MustNewConstMetric(v.probersDesc, prometheus.GaugeValue,
+			float64(verdict.Probers), verdict.Job, verdict.Target)
+		metrics <- prometheus.MustNewConstMetric(v.downDesc, prometheus.GaugeValue,
+			float64(verdict.Down), verdict.Job, verdict.Target)
+	}
+}
@@ -0,0 +1,102 @@
+package tasks
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/f1shl3gs/gossiping/tasks/targetpb"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func encodeReports(t *testing.T, reports ...*targetpb.HealthReport) []byte {
+	buf := bytes.NewBuffer(nil)
+	for _, hr := range reports {
+		_, err := pbutil.WriteDelimited(buf, hr)
+		require.NoError(t, err)
+	}
+
+	return buf.Bytes()
+}
+
+func healthReport(node string, updated time.Time, up ...bool) *targetpb.HealthReport {
+	hr := &targetpb.HealthReport{Node: node, Updated: updated}
+	for i, u := range up {
+		hr.Targets = append(hr.Targets, &targetpb.TargetHealth{
+			Job:    "job",
+			Target: string(rune('a' + i)),
+			Up:     u,
+		})
+	}
+
+	return hr
+}
+
+func TestVerdicts(t *testing.T) {
+	v := NewVerdicts(zaptest.NewLogger(t), nil, "self", DefaultQuorum, time.Minute)
+	now := time.Now()
+
+	// a is down from 1 of 3 probers, b is down from 2 of 3
+	err := v.Merge(encodeReports(t,
+		healthReport("n1", now, false, false),
+		healthReport("n2", now, true, false),
+		healthReport("n3", now, true, true)))
+	require.NoError(t, err)
+
+	require.Equal(t, []Verdict{
+		{Job: "job", Target: "a", Up: true, Probers: 3, Down: 1},
+		{Job: "job", Target: "b", Up: false, Probers: 3, Down: 2},
+	}, v.Verdicts())
+
+	// stale reports are ignored
+	err = v.Merge(encodeReports(t, healthReport("n3", now.Add(-time.Second), false, false)))
+	require.NoError(t, err)
+	require.True(t, v.Verdicts()[0].Up)
+
+	// the full state is merged by other nodes as it is
+	data, err := v.MarshalBinary()
+	require.NoError(t, err)
+
+	other := NewVerdicts(zaptest.NewLogger(t), nil, "other", DefaultQuorum, time.Minute)
+	require.NoError(t, other.Merge(data))
+	require.Equal(t, v.Verdicts(), other.Verdicts())
+
+	// reports of nodes gone expire
+	v.mtx.Lock()
+	v.reports["n1"].Updated = now.Add(-time.Hour)
+	v.mtx.Unlock()
+	require.Equal(t, []Verdict{
+		{Job: "job", Target: "a", Up: true, Probers: 2, Down: 0},
+		{Job: "job", Target: "b", Up: false, Probers: 2, Down: 1},
+	}, v.Verdicts())
+
+	// and they are not passed on
+	data, err = v.MarshalBinary()
+	require.NoError(t, err)
+	third := NewVerdicts(zaptest.NewLogger(t), nil, "third", DefaultQuorum, time.Minute)
+	require.NoError(t, third.Merge(data))
+	require.NotContains(t, third.reports, "n1")
+}
+
+func TestVerdictsExpiredNotMergedBack(t *testing.T) {
+	v := NewVerdicts(zaptest.NewLogger(t), nil, "self", DefaultQuorum, time.Minute)
+	now := time.Now()
+
+	dead := healthReport("dead", now.Add(-time.Hour), false)
+	v.mtx.Lock()
+	v.reports["dead"] = dead
+	v.prune(now)
+	v.mtx.Unlock()
+	require.Empty(t, v.reports)
+
+	// a peer still holding the report of the dead node pushes it back
+	require.NoError(t, v.Merge(encodeReports(t, dead)))
+	require.Empty(t, v.reports)
+	require.Empty(t, v.Verdicts())
+
+	// reports made a little ahead of the local clock are accepted
+	require.NoError(t, v.Merge(encodeReports(t, healthReport("skewed", now.Add(30*time.Second), true))))
+	require.Contains(t, v.reports, "skewed")
+}